@@ -0,0 +1,9 @@
+data "azurermext_cosmosdb_accounts" "production" {
+  scopes = ["/subscriptions/xxx", "/subscriptions/yyy/resourceGroups/data"]
+  tags   = { environment = "production" }
+}
+
+resource "azurermext_cosmosdb_ip_range_filter_bulk" "corporate_egress" {
+  cosmosdb_account_ids = [for account in data.azurermext_cosmosdb_accounts.production.accounts : account.id if account.public_network_access == "Enabled"]
+  ip_rules             = ["4.210.172.107", "13.88.56.148"]
+}
@@ -0,0 +1,7 @@
+data "azurermext_cosmosdb_ip_rules" "example" {
+  cosmosdb_account_id = "xxx" # attribute 'id' of an azurerm_cosmosdb_account
+}
+
+output "ip_rule_owners" {
+  value = { for rule in data.azurermext_cosmosdb_ip_rules.example.ip_rules : rule.ip => rule.owner }
+}
@@ -0,0 +1,13 @@
+data "azurermext_resource_graph_query" "public_cosmosdb_accounts" {
+  query = <<-KQL
+    resources
+    | where type =~ 'microsoft.documentdb/databaseaccounts'
+    | where properties.publicNetworkAccess =~ 'Enabled'
+    | project id, name, location
+  KQL
+}
+
+resource "azurermext_cosmosdb_ip_range_filter_bulk" "corporate_egress" {
+  cosmosdb_account_ids = [for row in data.azurermext_resource_graph_query.public_cosmosdb_accounts.rows : row.id]
+  ip_rules             = ["4.210.172.107", "13.88.56.148"]
+}
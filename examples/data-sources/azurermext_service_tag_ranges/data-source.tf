@@ -0,0 +1,10 @@
+data "azurermext_service_tag_ranges" "example" {
+  service_tags = ["AzureCloud.westeurope", "DataFactory"]
+  location     = "westeurope"
+}
+
+# Or from a file downloaded from https://www.microsoft.com/en-us/download/details.aspx?id=56519
+data "azurermext_service_tag_ranges" "from_file" {
+  service_tags = ["AzureCloud.westeurope", "DataFactory"]
+  file         = "${path.module}/ServiceTags_Public.json"
+}
@@ -0,0 +1,4 @@
+# true
+output "contains" {
+  value = provider::azurermext::cidr_contains("10.0.0.0/16", "10.0.1.0/24")
+}
@@ -0,0 +1,4 @@
+# ["10.0.0.0/24", "192.168.1.7"]
+output "merged" {
+  value = provider::azurermext::cidr_merge(["10.0.0.0/25", "10.0.0.128/25", "10.0.0.7", "192.168.1.7/32"])
+}
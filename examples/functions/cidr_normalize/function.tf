@@ -0,0 +1,9 @@
+# "10.0.0.0/24"
+output "normalized" {
+  value = provider::azurermext::cidr_normalize("10.0.0.1/24")
+}
+
+# Deduplicates IPs written in different ways
+locals {
+  ip_rules = distinct([for ip in var.allowed_ips : provider::azurermext::cidr_normalize(ip)])
+}
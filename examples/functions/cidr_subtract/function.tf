@@ -0,0 +1,4 @@
+# ["10.0.0.64/26", "10.0.0.128/25"]
+output "remaining" {
+  value = provider::azurermext::cidr_subtract(["10.0.0.0/24"], ["10.0.0.0/26"])
+}
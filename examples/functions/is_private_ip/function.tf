@@ -0,0 +1,4 @@
+# Private IPs have no effect on a public endpoint firewall
+locals {
+  ip_rules = [for ip in var.allowed_ips : ip if !provider::azurermext::is_private_ip(ip)]
+}
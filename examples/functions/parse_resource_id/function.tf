@@ -0,0 +1,8 @@
+locals {
+  account = provider::azurermext::parse_resource_id(azurerm_cosmosdb_account.example.id)
+}
+
+# "Microsoft.DocumentDB/databaseAccounts"
+output "resource_type" {
+  value = local.account.resource_type
+}
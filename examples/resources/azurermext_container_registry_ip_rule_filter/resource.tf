@@ -0,0 +1,5 @@
+resource "azurermext_container_registry_ip_rule_filter" "example" {
+  container_registry_id = "xxx" # attribute 'id' of an azurerm_container_registry
+
+  ip_rules = ["4.210.172.107", "13.88.56.148", "13.91.105.0/24"]
+}
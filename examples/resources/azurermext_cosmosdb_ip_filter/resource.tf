@@ -1,5 +1,10 @@
 resource "azurermext_cosmosdb_ip_range_filter" "example" {
   cosmosdb_account_id = "xxx" # attribute 'id' of an azurerm_cosmosdb_account
 
-  ip_rules = ["4.210.172.107", "13.88.56.148", "13.91.105.0/24"]
+  ip_rules = [
+    { ip = "4.210.172.107" },
+    { ip = "13.88.56.148" },
+    { ip = "13.91.105.0/24", description = "Reporting service", owner = "team-data" },
+    { ip = "203.0.113.7", expires_at = "2025-01-31T18:00:00Z" }, # temporary vendor access
+  ]
 }
@@ -0,0 +1,6 @@
+resource "azurermext_cosmosdb_ip_range_filter_bulk" "corporate_egress" {
+  scope                = "/subscriptions/xxx/resourceGroups/data" # every CosmosDB account of the resource group
+  cosmosdb_account_ids = ["xxx"]                                  # attribute 'id' of other azurerm_cosmosdb_account
+
+  ip_rules = ["4.210.172.107", "13.88.56.148", "13.91.105.0/24"]
+}
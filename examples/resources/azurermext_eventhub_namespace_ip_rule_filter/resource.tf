@@ -0,0 +1,5 @@
+resource "azurermext_eventhub_namespace_ip_rule_filter" "example" {
+  eventhub_namespace_id = "xxx" # attribute 'id' of an azurerm_eventhub_namespace
+
+  ip_rules = ["4.210.172.107", "13.88.56.148", "13.91.105.0/24"]
+}
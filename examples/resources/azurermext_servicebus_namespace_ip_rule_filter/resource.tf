@@ -0,0 +1,5 @@
+resource "azurermext_servicebus_namespace_ip_rule_filter" "example" {
+  servicebus_namespace_id = "xxx" # attribute 'id' of an azurerm_servicebus_namespace
+
+  ip_rules = ["4.210.172.107", "13.88.56.148", "13.91.105.0/24"]
+}
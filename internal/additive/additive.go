@@ -0,0 +1,173 @@
+// Package additive implements the "ignore foreign entries" list merge used by the IP filter resources.
+//
+// Terraform only owns the entries it has configured: entries added to the remote list by anyone else are kept
+// untouched, and an entry is only removed remotely when it used to be managed and no longer is.
+//
+// A service plugs into the engine through an Adapter describing how to read and write its remote list.
+// The merge itself is a pure function so it can be computed at plan time as well as during apply.
+package additive
+
+import "context"
+
+// Identity tells entries of a list apart.
+type Identity[T any] interface {
+	// Key returns the identity of an entry: two entries with the same key are the same entry.
+	Key(item T) string
+	// Equal reports whether two entries sharing a key are identical. An entry with a known key which isn't equal
+	// to its remote counterpart is updated in place.
+	Equal(a, b T) bool
+}
+
+// Adapter describes how to read and write the remote list of a single resource.
+type Adapter[T any] interface {
+	Identity[T]
+	// Read returns the entries currently set on the remote resource.
+	Read(ctx context.Context) ([]T, error)
+	// Write replaces the full remote list. It may return before the change is effective.
+	Write(ctx context.Context, items []T) error
+	// Poll blocks until the last Write is effective.
+	Poll(ctx context.Context) error
+}
+
+// Result describes the outcome of merging a desired list into a remote list.
+type Result[T any] struct {
+	// Final is the full list that has to be written to the remote resource.
+	Final []T
+	// Added are the desired entries missing from the remote list.
+	Added []T
+	// Updated are the desired entries present remotely with a different value.
+	Updated []T
+	// Removed are the previously managed entries that are no longer desired and exist remotely.
+	Removed []T
+}
+
+// Changed reports whether the remote list has to be written.
+func (r Result[T]) Changed() bool {
+	return len(r.Added) != 0 || len(r.Updated) != 0 || len(r.Removed) != 0
+}
+
+// Merge computes the remote list resulting from applying desired on top of current.
+// previous holds the entries managed before this change (nil on create).
+//
+// The remote order is preserved, updated entries are replaced where they stand and added entries are appended
+// in their desired order. Duplicated keys in desired are only taken into account once.
+func Merge[T any](id Identity[T], current, previous, desired []T) Result[T] {
+	result := Result[T]{Final: []T{}, Added: []T{}, Updated: []T{}, Removed: []T{}}
+
+	desiredByKey := make(map[string]T, len(desired))
+	for _, item := range desired {
+		if _, ok := desiredByKey[id.Key(item)]; !ok {
+			desiredByKey[id.Key(item)] = item
+		}
+	}
+	previousKeys := keySet(id, previous)
+
+	currentKeys := make(map[string]struct{}, len(current))
+	for _, item := range current {
+		key := id.Key(item)
+		currentKeys[key] = struct{}{}
+		wanted, isDesired := desiredByKey[key]
+		switch {
+		case isDesired && !id.Equal(item, wanted):
+			result.Updated = append(result.Updated, wanted)
+			result.Final = append(result.Final, wanted)
+		case isDesired:
+			result.Final = append(result.Final, item)
+		case hasKey(previousKeys, key):
+			result.Removed = append(result.Removed, item)
+		default:
+			result.Final = append(result.Final, item)
+		}
+	}
+
+	for _, item := range desired {
+		key := id.Key(item)
+		if hasKey(currentKeys, key) {
+			continue
+		}
+		currentKeys[key] = struct{}{}
+		result.Added = append(result.Added, item)
+	}
+	result.Final = append(result.Final, result.Added...)
+	return result
+}
+
+// Replace computes the remote list resulting from making it exactly desired: every remote entry which isn't
+// desired is removed, whoever added it. The remote order of the kept entries is preserved like in Merge.
+func Replace[T any](id Identity[T], current, desired []T) Result[T] {
+	return Merge(id, current, current, desired)
+}
+
+// Filter returns the managed entries still present in the remote list, preserving the managed order.
+// Entries are taken from the remote list so that remote changes to a managed entry surface as drift.
+func Filter[T any](id Identity[T], managed, current []T) []T {
+	currentByKey := make(map[string]T, len(current))
+	for _, item := range current {
+		currentByKey[id.Key(item)] = item
+	}
+	filtered := []T{}
+	for _, item := range managed {
+		if remote, ok := currentByKey[id.Key(item)]; ok {
+			filtered = append(filtered, remote)
+		}
+	}
+	return filtered
+}
+
+// Unmanaged returns the remote entries that aren't managed, preserving the remote order.
+func Unmanaged[T any](id Identity[T], managed, current []T) []T {
+	managedKeys := keySet(id, managed)
+	unmanaged := []T{}
+	for _, item := range current {
+		if !hasKey(managedKeys, id.Key(item)) {
+			unmanaged = append(unmanaged, item)
+		}
+	}
+	return unmanaged
+}
+
+// Commit writes the merged list through the adapter and waits for it to be effective.
+// Nothing is written when the merge didn't change anything.
+func Commit[T any](ctx context.Context, adapter Adapter[T], result Result[T]) error {
+	if !result.Changed() {
+		return nil
+	}
+	if err := adapter.Write(ctx, result.Final); err != nil {
+		return err
+	}
+	return adapter.Poll(ctx)
+}
+
+// Reconcile reads the remote list, merges desired into it and commits the result.
+func Reconcile[T any](ctx context.Context, adapter Adapter[T], previous, desired []T) (Result[T], error) {
+	current, err := adapter.Read(ctx)
+	if err != nil {
+		return Result[T]{}, err
+	}
+	result := Merge[T](adapter, current, previous, desired)
+	return result, Commit(ctx, adapter, result)
+}
+
+// Strings is the identity of plain string entries such as IP rules.
+type Strings struct{}
+
+func (Strings) Key(item string) string {
+	return item
+}
+
+func (Strings) Equal(a, b string) bool {
+	return a == b
+}
+
+func keySet[T any](id Identity[T], items []T) map[string]struct{} {
+	keys := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		keys[id.Key(item)] = struct{}{}
+	}
+	return keys
+}
+
+func hasKey(keys map[string]struct{}, key string) bool {
+	_, ok := keys[key]
+	return ok
+}
@@ -0,0 +1,372 @@
+package additive
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// rule is an entry whose key is its name and whose value can change in place.
+type rule struct {
+	name  string
+	value string
+}
+
+type ruleIdentity struct{}
+
+func (ruleIdentity) Key(item rule) string {
+	return item.name
+}
+
+func (ruleIdentity) Equal(a, b rule) bool {
+	return a == b
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name                       string
+		current, previous, desired []string
+		final                      []string
+		added, removed             []string
+	}{
+		{
+			name:    "create on empty list",
+			current: []string{}, previous: nil, desired: []string{"a", "b"},
+			final: []string{"a", "b"}, added: []string{"a", "b"}, removed: []string{},
+		},
+		{
+			name:    "create keeps foreign entries",
+			current: []string{"x", "y"}, previous: nil, desired: []string{"a"},
+			final: []string{"x", "y", "a"}, added: []string{"a"}, removed: []string{},
+		},
+		{
+			name:    "create with entries already present",
+			current: []string{"x", "a"}, previous: nil, desired: []string{"a"},
+			final: []string{"x", "a"}, added: []string{}, removed: []string{},
+		},
+		{
+			name:    "foreign entries kept on update",
+			current: []string{"x", "a", "y"}, previous: []string{"a"}, desired: []string{"a", "b"},
+			final: []string{"x", "a", "y", "b"}, added: []string{"b"}, removed: []string{},
+		},
+		{
+			name:    "previously managed entries removed",
+			current: []string{"x", "a", "b"}, previous: []string{"a", "b"}, desired: []string{"b"},
+			final: []string{"x", "b"}, added: []string{}, removed: []string{"a"},
+		},
+		{
+			name:    "previously managed entries already gone",
+			current: []string{"x"}, previous: []string{"a"}, desired: []string{},
+			final: []string{"x"}, added: []string{}, removed: []string{},
+		},
+		{
+			name:    "emptied desired list removes managed entries only",
+			current: []string{"x", "a"}, previous: []string{"a"}, desired: []string{},
+			final: []string{"x"}, added: []string{}, removed: []string{"a"},
+		},
+		{
+			name:    "duplicate desired keys added once",
+			current: []string{}, previous: nil, desired: []string{"a", "b", "a"},
+			final: []string{"a", "b"}, added: []string{"a", "b"}, removed: []string{},
+		},
+		{
+			name:    "remote order preserved and additions appended in desired order",
+			current: []string{"c", "x", "a"}, previous: []string{"a", "c"}, desired: []string{"e", "a", "d", "c"},
+			final: []string{"c", "x", "a", "e", "d"}, added: []string{"e", "d"}, removed: []string{},
+		},
+		{
+			name:    "no change",
+			current: []string{"x", "a"}, previous: []string{"a"}, desired: []string{"a"},
+			final: []string{"x", "a"}, added: []string{}, removed: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Merge[string](Strings{}, tt.current, tt.previous, tt.desired)
+			if !reflect.DeepEqual(result.Final, tt.final) {
+				t.Errorf("Final = %v, want %v", result.Final, tt.final)
+			}
+			if !reflect.DeepEqual(result.Added, tt.added) {
+				t.Errorf("Added = %v, want %v", result.Added, tt.added)
+			}
+			if !reflect.DeepEqual(result.Removed, tt.removed) {
+				t.Errorf("Removed = %v, want %v", result.Removed, tt.removed)
+			}
+			if len(result.Updated) != 0 {
+				t.Errorf("Updated = %v, want none", result.Updated)
+			}
+			if wantChanged := len(tt.added) != 0 || len(tt.removed) != 0; result.Changed() != wantChanged {
+				t.Errorf("Changed() = %v, want %v", result.Changed(), wantChanged)
+			}
+		})
+	}
+}
+
+func TestMergeUpdatesInPlace(t *testing.T) {
+	tests := []struct {
+		name                           string
+		current, previous, desired     []rule
+		final, added, updated, removed []rule
+	}{
+		{
+			name:     "changed value replaced where it stands",
+			current:  []rule{{"x", "1"}, {"a", "1"}, {"y", "1"}},
+			previous: []rule{{"a", "1"}},
+			desired:  []rule{{"a", "2"}},
+			final:    []rule{{"x", "1"}, {"a", "2"}, {"y", "1"}},
+			added:    []rule{}, updated: []rule{{"a", "2"}}, removed: []rule{},
+		},
+		{
+			name:     "foreign entry taken over with another value",
+			current:  []rule{{"a", "1"}},
+			previous: nil,
+			desired:  []rule{{"a", "2"}, {"b", "1"}},
+			final:    []rule{{"a", "2"}, {"b", "1"}},
+			added:    []rule{{"b", "1"}}, updated: []rule{{"a", "2"}}, removed: []rule{},
+		},
+		{
+			name:     "equal value left untouched",
+			current:  []rule{{"a", "1"}},
+			previous: []rule{{"a", "1"}},
+			desired:  []rule{{"a", "1"}},
+			final:    []rule{{"a", "1"}},
+			added:    []rule{}, updated: []rule{}, removed: []rule{},
+		},
+		{
+			name:     "first of duplicate desired keys wins",
+			current:  []rule{{"a", "1"}},
+			previous: []rule{{"a", "1"}},
+			desired:  []rule{{"a", "2"}, {"a", "3"}},
+			final:    []rule{{"a", "2"}},
+			added:    []rule{}, updated: []rule{{"a", "2"}}, removed: []rule{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Merge[rule](ruleIdentity{}, tt.current, tt.previous, tt.desired)
+			if !reflect.DeepEqual(result.Final, tt.final) {
+				t.Errorf("Final = %v, want %v", result.Final, tt.final)
+			}
+			if !reflect.DeepEqual(result.Added, tt.added) {
+				t.Errorf("Added = %v, want %v", result.Added, tt.added)
+			}
+			if !reflect.DeepEqual(result.Updated, tt.updated) {
+				t.Errorf("Updated = %v, want %v", result.Updated, tt.updated)
+			}
+			if !reflect.DeepEqual(result.Removed, tt.removed) {
+				t.Errorf("Removed = %v, want %v", result.Removed, tt.removed)
+			}
+		})
+	}
+}
+
+func TestReplace(t *testing.T) {
+	tests := []struct {
+		name                    string
+		current, desired, final []string
+		removed                 []string
+	}{
+		{
+			name:    "foreign entries dropped",
+			current: []string{"x", "a", "y"}, desired: []string{"a", "b"},
+			final: []string{"a", "b"}, removed: []string{"x", "y"},
+		},
+		{
+			name:    "order of kept entries preserved",
+			current: []string{"b", "x", "a"}, desired: []string{"a", "b", "c"},
+			final: []string{"b", "a", "c"}, removed: []string{"x"},
+		},
+		{
+			name:    "empty desired empties the list",
+			current: []string{"x"}, desired: []string{},
+			final: []string{}, removed: []string{"x"},
+		},
+		{
+			name:    "already exact",
+			current: []string{"a", "b"}, desired: []string{"b", "a"},
+			final: []string{"a", "b"}, removed: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Replace[string](Strings{}, tt.current, tt.desired)
+			if !reflect.DeepEqual(result.Final, tt.final) {
+				t.Errorf("Final = %v, want %v", result.Final, tt.final)
+			}
+			if !reflect.DeepEqual(result.Removed, tt.removed) {
+				t.Errorf("Removed = %v, want %v", result.Removed, tt.removed)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name                   string
+		managed, current, want []rule
+	}{
+		{
+			name:    "managed order kept, missing entries dropped",
+			managed: []rule{{"b", "1"}, {"a", "1"}, {"c", "1"}},
+			current: []rule{{"a", "1"}, {"x", "1"}, {"b", "1"}},
+			want:    []rule{{"b", "1"}, {"a", "1"}},
+		},
+		{
+			name:    "remote value surfaces as drift",
+			managed: []rule{{"a", "1"}},
+			current: []rule{{"a", "2"}},
+			want:    []rule{{"a", "2"}},
+		},
+		{
+			name:    "nothing managed",
+			managed: nil,
+			current: []rule{{"x", "1"}},
+			want:    []rule{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Filter[rule](ruleIdentity{}, tt.managed, tt.current); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Filter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmanaged(t *testing.T) {
+	tests := []struct {
+		name                   string
+		managed, current, want []string
+	}{
+		{name: "remote order kept", managed: []string{"a"}, current: []string{"y", "a", "x"}, want: []string{"y", "x"}},
+		{name: "everything managed", managed: []string{"a", "b"}, current: []string{"b", "a"}, want: []string{}},
+		{name: "nothing managed", managed: nil, current: []string{"x"}, want: []string{"x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unmanaged[string](Strings{}, tt.managed, tt.current); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmanaged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeAdapter records the calls of the engine.
+type fakeAdapter struct {
+	Strings
+	current  []string
+	readErr  error
+	writeErr error
+	calls    []string
+	written  []string
+}
+
+func (a *fakeAdapter) Read(_ context.Context) ([]string, error) {
+	a.calls = append(a.calls, "read")
+	return a.current, a.readErr
+}
+
+func (a *fakeAdapter) Write(_ context.Context, items []string) error {
+	a.calls = append(a.calls, "write")
+	a.written = items
+	return a.writeErr
+}
+
+func (a *fakeAdapter) Poll(_ context.Context) error {
+	a.calls = append(a.calls, "poll")
+	return nil
+}
+
+func TestCommit(t *testing.T) {
+	errWrite := errors.New("write failed")
+	tests := []struct {
+		name     string
+		result   Result[string]
+		writeErr error
+		calls    []string
+		err      error
+	}{
+		{
+			name:   "unchanged result not written",
+			result: Result[string]{Final: []string{"a"}, Added: []string{}, Updated: []string{}, Removed: []string{}},
+			calls:  nil,
+		},
+		{
+			name:   "changed result written then polled",
+			result: Result[string]{Final: []string{"a", "b"}, Added: []string{"b"}},
+			calls:  []string{"write", "poll"},
+		},
+		{
+			name:   "removal alone written",
+			result: Result[string]{Final: []string{}, Removed: []string{"a"}},
+			calls:  []string{"write", "poll"},
+		},
+		{
+			name:     "failed write not polled",
+			result:   Result[string]{Final: []string{"a"}, Added: []string{"a"}},
+			writeErr: errWrite,
+			calls:    []string{"write"},
+			err:      errWrite,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeAdapter{writeErr: tt.writeErr}
+			if err := Commit[string](context.Background(), adapter, tt.result); !errors.Is(err, tt.err) {
+				t.Fatalf("Commit() error = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(adapter.calls, tt.calls) {
+				t.Errorf("calls = %v, want %v", adapter.calls, tt.calls)
+			}
+			if tt.calls != nil && !reflect.DeepEqual(adapter.written, tt.result.Final) {
+				t.Errorf("written = %v, want %v", adapter.written, tt.result.Final)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	errRead := errors.New("read failed")
+	tests := []struct {
+		name              string
+		current           []string
+		readErr           error
+		previous, desired []string
+		calls             []string
+		written           []string
+		err               error
+	}{
+		{
+			name:    "merged list written",
+			current: []string{"x", "a"}, previous: []string{"a"}, desired: []string{"b"},
+			calls: []string{"read", "write", "poll"}, written: []string{"x", "b"},
+		},
+		{
+			name:    "up to date list not written",
+			current: []string{"x", "a"}, previous: []string{"a"}, desired: []string{"a"},
+			calls: []string{"read"},
+		},
+		{
+			name:    "read failure stops before writing",
+			readErr: errRead, desired: []string{"a"},
+			calls: []string{"read"}, err: errRead,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &fakeAdapter{current: tt.current, readErr: tt.readErr}
+			_, err := Reconcile[string](context.Background(), adapter, tt.previous, tt.desired)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Reconcile() error = %v, want %v", err, tt.err)
+			}
+			if got := strings.Join(adapter.calls, ","); got != strings.Join(tt.calls, ",") {
+				t.Errorf("calls = %v, want %v", got, strings.Join(tt.calls, ","))
+			}
+			if !reflect.DeepEqual(adapter.written, tt.written) {
+				t.Errorf("written = %v, want %v", adapter.written, tt.written)
+			}
+		})
+	}
+}
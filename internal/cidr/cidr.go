@@ -0,0 +1,194 @@
+// Package cidr manipulates lists of IP addresses and CIDR ranges such as the IP rules of Azure firewalls.
+//
+// Azure accepts a single address either as "10.0.0.1" or "10.0.0.1/32". This package returns single addresses
+// without their prefix length, which is how the Azure portal writes them.
+package cidr
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// ParsePrefix parses an IP address or a CIDR range. An address is returned as a single address prefix and the
+// host bits of a range are cleared, e.g. "10.0.0.1/24" is 10.0.0.0/24 as Azure reads it.
+func ParsePrefix(value string) (netip.Prefix, error) {
+	if !strings.Contains(value, "/") {
+		addr, err := netip.ParseAddr(value)
+		// A zone only makes sense for a link-local address of the local host.
+		if err != nil || addr.Zone() != "" {
+			return netip.Prefix{}, fmt.Errorf("%q is neither an IP address nor a CIDR range", value)
+		}
+		return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(value)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%q is neither an IP address nor a CIDR range", value)
+	}
+	return prefix.Masked(), nil
+}
+
+// Format writes a prefix the way Azure does, single addresses without their prefix length.
+func Format(prefix netip.Prefix) string {
+	if prefix.IsSingleIP() {
+		return prefix.Addr().String()
+	}
+	return prefix.String()
+}
+
+// Normalize parses an IP address or CIDR range and formats it back the way Azure does, e.g. "10.0.0.1/24" is
+// "10.0.0.0/24" and "10.0.0.1/32" is "10.0.0.1".
+func Normalize(value string) (string, error) {
+	prefix, err := ParsePrefix(value)
+	if err != nil {
+		return "", err
+	}
+	return Format(prefix), nil
+}
+
+// Contains reports whether every address of value, an IP address or CIDR range, is in prefix.
+func Contains(prefix, value string) (bool, error) {
+	outer, err := ParsePrefix(prefix)
+	if err != nil {
+		return false, err
+	}
+	inner, err := ParsePrefix(value)
+	if err != nil {
+		return false, err
+	}
+	return outer.Bits() <= inner.Bits() && outer.Contains(inner.Addr()), nil
+}
+
+// Merge returns the smallest list of prefixes covering exactly the same addresses as values: duplicates and
+// ranges contained in others are dropped, and adjacent ranges are joined, e.g. 10.0.0.0/25 and 10.0.0.128/25
+// become 10.0.0.0/24. The result is sorted, IPv4 first.
+func Merge(values []string) ([]string, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		prefix, err := ParsePrefix(value)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	merged := []string{}
+	for _, prefix := range mergePrefixes(prefixes) {
+		merged = append(merged, Format(prefix))
+	}
+	return merged, nil
+}
+
+// Subtract returns the smallest list of prefixes covering the addresses of values which aren't in excluded, e.g.
+// 10.0.0.0/24 minus 10.0.0.0/26 is 10.0.0.64/26 and 10.0.0.128/25. The result is sorted like Merge's.
+func Subtract(values, excluded []string) ([]string, error) {
+	var prefixes, excludedPrefixes []netip.Prefix
+	for _, list := range []struct {
+		values   []string
+		prefixes *[]netip.Prefix
+	}{{values, &prefixes}, {excluded, &excludedPrefixes}} {
+		for _, value := range list.values {
+			prefix, err := ParsePrefix(value)
+			if err != nil {
+				return nil, err
+			}
+			*list.prefixes = append(*list.prefixes, prefix)
+		}
+	}
+	remaining := mergePrefixes(prefixes)
+	for _, excludedPrefix := range mergePrefixes(excludedPrefixes) {
+		var next []netip.Prefix
+		for _, prefix := range remaining {
+			next = append(next, subtractPrefix(prefix, excludedPrefix)...)
+		}
+		remaining = next
+	}
+	result := []string{}
+	for _, prefix := range mergePrefixes(remaining) {
+		result = append(result, Format(prefix))
+	}
+	return result, nil
+}
+
+// privateRanges are the ranges netip.Addr.IsPrivate accepts: RFC 1918 for IPv4 and RFC 4193 for IPv6.
+var privateRanges = []netip.Prefix{
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("fc00::/7"),
+}
+
+// IsPrivate reports whether every address of value, an IP address or CIDR range, is private. Private addresses
+// can't reach the public endpoint of an Azure resource, so firewall rules allowing them have no effect.
+func IsPrivate(value string) (bool, error) {
+	prefix, err := ParsePrefix(value)
+	if err != nil {
+		return false, err
+	}
+	for _, private := range privateRanges {
+		if private.Bits() <= prefix.Bits() && private.Contains(prefix.Addr()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// subtractPrefix returns the parts of prefix outside of excluded. When prefix strictly contains excluded, it's split
+// in halves down to excluded, keeping every half which doesn't hold it.
+func subtractPrefix(prefix, excluded netip.Prefix) []netip.Prefix {
+	if !prefix.Overlaps(excluded) {
+		return []netip.Prefix{prefix}
+	}
+	var parts []netip.Prefix
+	for prefix.Bits() < excluded.Bits() {
+		low := netip.PrefixFrom(prefix.Addr(), prefix.Bits()+1)
+		high := netip.PrefixFrom(setBit(prefix.Addr(), prefix.Bits()), prefix.Bits()+1)
+		if low.Contains(excluded.Addr()) {
+			parts, prefix = append(parts, high), low
+		} else {
+			parts, prefix = append(parts, low), high
+		}
+	}
+	return parts
+}
+
+// setBit sets the bit of addr at index i, counted from the most significant bit.
+func setBit(addr netip.Addr, i int) netip.Addr {
+	bytes := addr.AsSlice()
+	bytes[i/8] |= 0x80 >> (i % 8)
+	addr, _ = netip.AddrFromSlice(bytes)
+	return addr
+}
+
+func mergePrefixes(prefixes []netip.Prefix) []netip.Prefix {
+	// Sorted by address then widest first, a prefix can only be contained in the last kept one.
+	sort.Slice(prefixes, func(i, j int) bool {
+		if c := prefixes[i].Addr().Compare(prefixes[j].Addr()); c != 0 {
+			return c < 0
+		}
+		return prefixes[i].Bits() < prefixes[j].Bits()
+	})
+	merged := []netip.Prefix{}
+	for _, prefix := range prefixes {
+		if n := len(merged); n != 0 && merged[n-1].Contains(prefix.Addr()) && merged[n-1].Bits() <= prefix.Bits() {
+			continue
+		}
+		merged = append(merged, prefix)
+		// Joining two siblings may make their parent the sibling of the previous prefix.
+		for n := len(merged); n >= 2 && areSiblings(merged[n-2], merged[n-1]); n = len(merged) {
+			parent, _ := merged[n-1].Addr().Prefix(merged[n-1].Bits() - 1)
+			merged = append(merged[:n-2], parent)
+		}
+	}
+	return merged
+}
+
+// areSiblings reports whether a and b are the two halves of the same prefix.
+func areSiblings(a, b netip.Prefix) bool {
+	if a.Bits() != b.Bits() || a.Bits() == 0 || a.Addr().Is4() != b.Addr().Is4() || a == b {
+		return false
+	}
+	parentA, _ := a.Addr().Prefix(a.Bits() - 1)
+	parentB, _ := b.Addr().Prefix(b.Bits() - 1)
+	return parentA == parentB
+}
@@ -0,0 +1,203 @@
+package cidr
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "10.0.0.1", want: "10.0.0.1"},
+		{value: "10.0.0.1/32", want: "10.0.0.1"},
+		{value: "10.0.0.1/24", want: "10.0.0.0/24"},
+		{value: "0.0.0.0/0", want: "0.0.0.0/0"},
+		{value: "::ffff:10.0.0.1", want: "10.0.0.1"},
+		{value: "2001:DB8::1", want: "2001:db8::1"},
+		{value: "2001:db8::1/128", want: "2001:db8::1"},
+		{value: "2001:db8::1/48", want: "2001:db8::/48"},
+		{value: "", wantErr: true},
+		{value: "10.0.0", wantErr: true},
+		{value: "10.0.0.256", wantErr: true},
+		{value: "10.0.0.0/33", wantErr: true},
+		{value: "10.0.0.0/", wantErr: true},
+		{value: "2001:db8::/129", wantErr: true},
+		{value: "example.com", wantErr: true},
+		{value: "fe80::1%eth0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := Normalize(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Normalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		name    string
+		prefix  string
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{name: "address in range", prefix: "10.0.0.0/24", value: "10.0.0.7", want: true},
+		{name: "address out of range", prefix: "10.0.0.0/24", value: "10.0.1.7"},
+		{name: "narrower range", prefix: "10.0.0.0/16", value: "10.0.3.0/24", want: true},
+		{name: "wider range", prefix: "10.0.3.0/24", value: "10.0.0.0/16"},
+		{name: "same range", prefix: "10.0.0.0/24", value: "10.0.0.0/24", want: true},
+		{name: "host bits", prefix: "10.0.0.1/24", value: "10.0.0.200", want: true},
+		{name: "address and /32", prefix: "10.0.0.1", value: "10.0.0.1/32", want: true},
+		{name: "IPv6", prefix: "2001:db8::/32", value: "2001:db8:1::1", want: true},
+		{name: "IPv4 in IPv6", prefix: "::/0", value: "10.0.0.1"},
+		{name: "IPv6 in IPv4", prefix: "0.0.0.0/0", value: "2001:db8::1"},
+		{name: "invalid prefix", prefix: "10.0.0.0/40", value: "10.0.0.1", wantErr: true},
+		{name: "invalid value", prefix: "10.0.0.0/24", value: "10.0.0.x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Contains(tt.prefix, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Contains() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Contains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", values: nil, want: []string{}},
+		{name: "adjacent", values: []string{"10.0.0.128/25", "10.0.0.0/25"}, want: []string{"10.0.0.0/24"}},
+		{name: "adjacent addresses", values: []string{"10.0.0.1", "10.0.0.0"}, want: []string{"10.0.0.0/31"}},
+		{name: "cascading joins", values: []string{"10.0.0.0/26", "10.0.0.64/26", "10.0.0.128/25"}, want: []string{"10.0.0.0/24"}},
+		{name: "adjacent but not siblings", values: []string{"10.0.0.128/25", "10.0.1.0/25"}, want: []string{"10.0.0.128/25", "10.0.1.0/25"}},
+		{name: "overlapping", values: []string{"10.0.0.0/24", "10.0.0.0/16", "10.0.5.0/24"}, want: []string{"10.0.0.0/16"}},
+		{name: "contained address", values: []string{"10.0.0.7", "10.0.0.0/24"}, want: []string{"10.0.0.0/24"}},
+		{name: "address and /32", values: []string{"10.0.0.1", "10.0.0.1/32"}, want: []string{"10.0.0.1"}},
+		{name: "host bits", values: []string{"10.0.0.1/24", "10.0.1.0/24"}, want: []string{"10.0.0.0/23"}},
+		{name: "duplicates", values: []string{"10.0.0.1", "10.0.0.1", "10.0.0.2"}, want: []string{"10.0.0.1", "10.0.0.2"}},
+		{
+			name:   "IPv4 and IPv6",
+			values: []string{"2001:db8::/33", "10.0.0.0/25", "2001:db8:8000::/33", "10.0.0.128/25"},
+			want:   []string{"10.0.0.0/24", "2001:db8::/32"},
+		},
+		{name: "IPv4 and IPv6 zero prefixes", values: []string{"::/1", "0.0.0.0/1"}, want: []string{"0.0.0.0/1", "::/1"}},
+		{name: "whole address space", values: []string{"0.0.0.0/1", "128.0.0.0/1"}, want: []string{"0.0.0.0/0"}},
+		{name: "invalid", values: []string{"10.0.0.1", "10.0.0.1/"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Merge(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Merge() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(got, tt.want) {
+				t.Errorf("Merge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubtract(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		excluded []string
+		want     []string
+		wantErr  bool
+	}{
+		{name: "nothing excluded", values: []string{"10.0.0.0/24"}, excluded: nil, want: []string{"10.0.0.0/24"}},
+		{name: "split", values: []string{"10.0.0.0/24"}, excluded: []string{"10.0.0.0/26"}, want: []string{"10.0.0.64/26", "10.0.0.128/25"}},
+		{
+			name:     "split around an address",
+			values:   []string{"10.0.0.0/29"},
+			excluded: []string{"10.0.0.5"},
+			want:     []string{"10.0.0.0/30", "10.0.0.4", "10.0.0.6/31"},
+		},
+		{name: "whole range", values: []string{"10.0.0.0/24"}, excluded: []string{"10.0.0.0/16"}, want: []string{}},
+		{name: "address and /32", values: []string{"10.0.0.1", "10.0.0.2"}, excluded: []string{"10.0.0.1/32"}, want: []string{"10.0.0.2"}},
+		{name: "disjoint", values: []string{"10.0.0.0/24"}, excluded: []string{"10.0.1.0/24"}, want: []string{"10.0.0.0/24"}},
+		{
+			name:     "several exclusions",
+			values:   []string{"10.0.0.0/24"},
+			excluded: []string{"10.0.0.0/25", "10.0.0.192/26"},
+			want:     []string{"10.0.0.128/26"},
+		},
+		{
+			name:     "IPv6 excluded from IPv4",
+			values:   []string{"10.0.0.0/24", "2001:db8::/32"},
+			excluded: []string{"::/0"},
+			want:     []string{"10.0.0.0/24"},
+		},
+		{
+			name:     "IPv6 split",
+			values:   []string{"2001:db8::/32"},
+			excluded: []string{"2001:db8::/34"},
+			want:     []string{"2001:db8:4000::/34", "2001:db8:8000::/33"},
+		},
+		{name: "invalid value", values: []string{"10.0.0.0/24/1"}, excluded: nil, wantErr: true},
+		{name: "invalid exclusion", values: []string{"10.0.0.0/24"}, excluded: []string{"10.0.0.0/24/1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Subtract(tt.values, tt.excluded)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Subtract() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(got, tt.want) {
+				t.Errorf("Subtract() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPrivate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{value: "10.1.2.3", want: true},
+		{value: "10.0.0.0/8", want: true},
+		{value: "10.0.0.0/7"},
+		{value: "172.16.0.0/12", want: true},
+		{value: "172.32.0.1"},
+		{value: "192.168.1.0/24", want: true},
+		{value: "192.169.0.1"},
+		{value: "8.8.8.8"},
+		{value: "100.64.0.1"},
+		{value: "127.0.0.1"},
+		{value: "fd00::1", want: true},
+		{value: "fc00::/7", want: true},
+		{value: "2001:db8::1"},
+		{value: "::ffff:192.168.0.1", want: true},
+		{value: "private", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := IsPrivate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IsPrivate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("IsPrivate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"terraform-provider-azurermext/internal/resourceid"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// apiVersions pins the api-version used for every resource type the provider talks to.
+// Keys are lower-cased since ARM resource types are case-insensitive.
+var apiVersions = map[string]string{
+	"microsoft.documentdb/databaseaccounts":           "2025-04-15",
+	"microsoft.containerregistry/registries":          "2023-07-01",
+	"microsoft.eventhub/namespaces/networkrulesets":   "2024-01-01",
+	"microsoft.servicebus/namespaces/networkrulesets": "2021-11-01",
+}
+
+const (
+	maxRetries     = 3
+	retryBaseDelay = 2 * time.Second
+)
+
+// Get reads a resource and decodes it into T.
+func Get[T any](ctx context.Context, c *Client, id resourceid.ID) (*T, error) {
+	url, err := c.resourceURL(id)
+	if err != nil {
+		return nil, err
+	}
+	_, body, err := c.do(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		var armErr *ARMError
+		if errors.As(err, &armErr) && armErr.StatusCode == http.StatusNotFound {
+			return nil, NewNotFoundError(id.String())
+		}
+		return nil, err
+	}
+	var result T
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Patch sends a partial update, waits for it to complete and returns the updated resource.
+func Patch[T any](ctx context.Context, c *Client, id resourceid.ID, body any) (*T, error) {
+	operation, err := BeginPatch(ctx, c, id, body)
+	if err != nil {
+		return nil, err
+	}
+	return waitAndGet[T](ctx, c, id, operation)
+}
+
+// Put creates or replaces a resource, waits for it to complete and returns the resulting resource.
+func Put[T any](ctx context.Context, c *Client, id resourceid.ID, body any) (*T, error) {
+	operation, err := BeginPut(ctx, c, id, body)
+	if err != nil {
+		return nil, err
+	}
+	return waitAndGet[T](ctx, c, id, operation)
+}
+
+// Delete deletes a resource and waits for the deletion to complete. Deleting a missing resource is not an error.
+func Delete(ctx context.Context, c *Client, id resourceid.ID) error {
+	operation, err := BeginDelete(ctx, c, id)
+	if err != nil {
+		return err
+	}
+	return operation.Wait(ctx)
+}
+
+// BeginPatch sends a partial update and returns the operation to wait on.
+func BeginPatch(ctx context.Context, c *Client, id resourceid.ID, body any) (*Operation, error) {
+	return c.begin(ctx, http.MethodPatch, id, body)
+}
+
+// BeginPut sends a create or replace and returns the operation to wait on.
+func BeginPut(ctx context.Context, c *Client, id resourceid.ID, body any) (*Operation, error) {
+	return c.begin(ctx, http.MethodPut, id, body)
+}
+
+// BeginDelete sends a delete and returns the operation to wait on.
+func BeginDelete(ctx context.Context, c *Client, id resourceid.ID) (*Operation, error) {
+	operation, err := c.begin(ctx, http.MethodDelete, id, nil)
+	var armErr *ARMError
+	if errors.As(err, &armErr) && armErr.StatusCode == http.StatusNotFound {
+		return &Operation{}, nil
+	}
+	return operation, err
+}
+
+func waitAndGet[T any](ctx context.Context, c *Client, id resourceid.ID, operation *Operation) (*T, error) {
+	if err := operation.Wait(ctx); err != nil {
+		return nil, err
+	}
+	// A synchronous answer already holds the final resource, otherwise the body is a snapshot from
+	// before the operation completed and has to be read again.
+	if operation.synchronous && len(operation.body) != 0 {
+		var result T
+		if err := json.Unmarshal(operation.body, &result); err != nil {
+			return nil, err
+		}
+		return &result, nil
+	}
+	return Get[T](ctx, c, id)
+}
+
+func (c *Client) begin(ctx context.Context, method string, id resourceid.ID, body any) (*Operation, error) {
+	url, err := c.resourceURL(id)
+	if err != nil {
+		return nil, err
+	}
+	resp, respBody, err := c.do(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	operation := &Operation{client: c, body: respBody, retryAfter: parseRetryAfter(resp)}
+	if asyncUrl := resp.Header.Get("Azure-AsyncOperation"); asyncUrl != "" {
+		operation.pollUrl = asyncUrl
+	} else if location := resp.Header.Get("Location"); location != "" && resp.StatusCode == http.StatusAccepted {
+		operation.pollUrl = location
+		operation.locationPolling = true
+	} else {
+		operation.synchronous = true
+	}
+	tflog.Debug(ctx, "Async operation url: "+operation.pollUrl)
+	return operation, nil
+}
+
+// Operation is a possibly long-running ARM operation.
+type Operation struct {
+	client *Client
+	// pollUrl is the `Azure-AsyncOperation` url, or the `Location` url when locationPolling is set.
+	pollUrl         string
+	locationPolling bool
+	synchronous     bool
+	body            []byte
+	// retryAfter is the `Retry-After` sent with the initial response, if any.
+	retryAfter time.Duration
+}
+
+// Wait blocks until the operation is finished. It returns immediately for synchronous operations.
+func (o *Operation) Wait(ctx context.Context) error {
+	if o == nil || o.pollUrl == "" {
+		return nil
+	}
+	delay := o.client.pollInterval
+	if o.retryAfter > 0 {
+		delay = o.retryAfter
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay): // Since Go 1.23 this isn't a memory leak anymore.
+			finished, retryAfter, err := o.poll(ctx)
+			if err != nil {
+				return err
+			}
+			if finished {
+				return nil
+			}
+			delay = o.client.pollInterval
+			if retryAfter > 0 {
+				delay = retryAfter
+			}
+		}
+	}
+}
+
+func (o *Operation) poll(ctx context.Context) (finished bool, retryAfter time.Duration, err error) {
+	// Every poll asks for a token again in case the previous one expired, tokens are cached so this is cheap.
+	resp, body, err := o.client.do(ctx, http.MethodGet, o.pollUrl, nil)
+	if err != nil {
+		return false, 0, err
+	}
+	retryAfter = parseRetryAfter(resp)
+	if o.locationPolling {
+		return resp.StatusCode != http.StatusAccepted, retryAfter, nil
+	}
+
+	var pollResponse PollResponse
+	if err := json.Unmarshal(body, &pollResponse); err != nil {
+		return false, 0, err
+	}
+	tflog.Debug(ctx, "Async operation response: "+string(pollResponse.Status))
+	if pollResponse.Status.IsSuccess() {
+		return true, 0, nil
+	} else if pollResponse.Status.IsPending() {
+		return false, retryAfter, nil
+	}
+	return false, 0, newAsyncOperationError(resp, &pollResponse)
+}
+
+// do sends a request to ARM, retrying throttled and transient failures.
+// Any response with a status code of 400 or above is returned as an *ARMError.
+func (c *Client) do(ctx context.Context, method, url string, body any) (*http.Response, []byte, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, respBody, err := c.send(ctx, method, url, payload)
+		if attempt < maxRetries && isRetryable(resp, err) && ctx.Err() == nil {
+			delay := parseRetryAfter(resp)
+			if delay == 0 {
+				delay = retryBaseDelay << attempt
+			}
+			tflog.Debug(ctx, fmt.Sprintf("Retrying %s %s in %s", method, url, delay))
+			select {
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			case <-time.After(delay):
+			}
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if resp.StatusCode >= http.StatusBadRequest {
+			return resp, respBody, newARMError(resp, respBody)
+		}
+		return resp, respBody, nil
+	}
+}
+
+// send sends a request authenticated for the tenant of the target subscription. The tenant of a subscription
+// outside of the client's tenant is learnt from the challenge of the first request rejected by ARM.
+func (c *Client) send(ctx context.Context, method, url string, payload []byte) (*http.Response, []byte, error) {
+	tenantId := c.tenantFor(url)
+	resp, respBody, err := c.sendAs(ctx, tenantId, method, url, payload)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, respBody, err
+	}
+	if challenged := challengedTenant(resp); challenged != "" && !strings.EqualFold(challenged, tenantId) && c.learnTenant(ctx, url, challenged) {
+		return c.sendAs(ctx, challenged, method, url, payload)
+	}
+	return resp, respBody, nil
+}
+
+func (c *Client) sendAs(ctx context.Context, tenantId, method, url string, payload []byte) (_ *http.Response, _ []byte, cErr error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := c.authorize(ctx, req, tenantId); err != nil {
+		return nil, nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer captureErr(&cErr, resp.Body.Close)
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, respBody, nil
+}
+
+func isRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		var authErr *AuthenticationError
+		return !errors.As(err, &authErr) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads the `Retry-After` header, expressed in seconds by ARM.
+func parseRetryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func (c *Client) resourceURL(id resourceid.ID) (string, error) {
+	apiVersion, ok := apiVersions[strings.ToLower(id.ResourceType())]
+	if !ok {
+		return "", fmt.Errorf("no api-version known for resource type %s", id.ResourceType())
+	}
+	return c.resourceManagerEndpoint + id.String() + "?api-version=" + apiVersion, nil
+}
@@ -1,78 +1,161 @@
 package client
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
 	"net/http"
-	"net/url"
 	"strings"
 	"sync"
+	"terraform-provider-azurermext/internal/servicetags"
 	"time"
 )
 
-type authToken struct {
-	token     string
-	expiresAt int64
-}
-
 type Client struct {
+	// lock guards tokens, inflight, subscriptionTenants, serviceTags and resourceLocks, it's never held during
+	// network calls.
 	lock         sync.Mutex
-	authToken    authToken
+	tokens       map[tokenKey]authToken
+	inflight     map[tokenKey]*tokenFetch
 	clientId     string
 	clientSecret string
 	tenantId     string
+
+	subscriptionId     string
+	auxiliaryTenantIds []string
+	// subscriptionTenants maps the lower-cased ID of subscriptions outside of tenantId to their tenant.
+	subscriptionTenants map[string]string
+	// serviceTags caches ReadServiceTags by lower-cased "{subscription}/{location}".
+	serviceTags map[string]*servicetags.ServiceTags
+	// resourceLocks holds the lock of every resource by lower-cased ID. See LockResource.
+	resourceLocks map[string]*sync.Mutex
+
+	httpClient              *http.Client
+	resourceManagerEndpoint string
+	authorityHost           string
+	pollInterval            time.Duration
+	tokenCache              *FileTokenCache
+	permissionCheck         bool
+	// updateSlots bounds the updates running at once, unbounded when nil. See AcquireUpdateSlot.
+	updateSlots chan struct{}
 }
 
-func New(clientId, clientSecret, tenantId string) *Client {
-	return &Client{sync.Mutex{}, authToken{}, clientId, clientSecret, tenantId}
+const (
+	defaultResourceManagerEndpoint = "https://management.azure.com"
+	defaultAuthorityHost           = "https://login.microsoftonline.com"
+	defaultPollInterval            = 10 * time.Second
+)
+
+// Option customizes a Client. The defaults target the Azure public cloud.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for every ARM and token request.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
 }
 
-func (c *Client) GetToken() (string, error) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	if time.Now().Unix()+60 > c.authToken.expiresAt {
-		err := c.refreshAuthToken()
-		if err != nil {
-			return "", err
-		}
+// WithResourceManagerEndpoint sets the ARM base URL, e.g. "https://management.azure.com".
+func WithResourceManagerEndpoint(endpoint string) Option {
+	return func(c *Client) {
+		c.resourceManagerEndpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// WithAuthorityHost sets the AAD base URL, e.g. "https://login.microsoftonline.com".
+func WithAuthorityHost(host string) Option {
+	return func(c *Client) {
+		c.authorityHost = strings.TrimSuffix(host, "/")
+	}
+}
+
+// WithPollInterval sets the default wait between two polls of an async operation.
+// A `Retry-After` header sent by Azure takes precedence.
+func WithPollInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		c.pollInterval = interval
 	}
-	return c.authToken.token, nil
 }
 
-func (c *Client) refreshAuthToken() (cErr error) {
-	reqBody := url.Values{}
-	reqBody.Set("grant_type", "client_credentials")
-	reqBody.Set("client_id", c.clientId)
-	reqBody.Set("client_secret", c.clientSecret)
-	reqBody.Set("scope", "https://management.core.windows.net//.default")
-	req, err := http.NewRequest(
-		"POST",
-		fmt.Sprintf("https://login.microsoftonline.com/%s/oauth2/v2.0/token", c.tenantId),
-		strings.NewReader(reqBody.Encode()),
-	)
-	if err != nil {
-		return err
+// WithSubscriptionID sets the default subscription, used by data sources listing resources when none is given.
+func WithSubscriptionID(subscriptionId string) Option {
+	return func(c *Client) {
+		c.subscriptionId = subscriptionId
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
+}
+
+// WithAuxiliaryTenantIDs sets the tenants, besides the client's own, that the service principal can authenticate
+// to. Requests to subscriptions of these tenants are authenticated with a token of their tenant, and the tokens of
+// the other tenants that can be fetched are sent in the `x-ms-authorization-auxiliary` header for cross-tenant
+// operations.
+// ARM accepts at most 3 auxiliary tenants.
+func WithAuxiliaryTenantIDs(tenantIds ...string) Option {
+	return func(c *Client) {
+		c.auxiliaryTenantIds = tenantIds
 	}
-	defer captureErr(&cErr, resp.Body.Close)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to request token. Response status: %d. Request query params: %s", resp.StatusCode, req.URL.RawQuery)
+// WithMaxConcurrentUpdates bounds the long-running updates run at once by every resource sharing the client, see
+// AcquireUpdateSlot. Zero means unbounded.
+func WithMaxConcurrentUpdates(n int) Option {
+	return func(c *Client) {
+		c.updateSlots = nil
+		if n > 0 {
+			c.updateSlots = make(chan struct{}, n)
+		}
 	}
+}
 
-	var tokenResponse struct {
-		Token     string `json:"access_token"`
-		ExpiresIn int64  `json:"expires_in"`
+func New(clientId, clientSecret, tenantId string, opts ...Option) *Client {
+	c := &Client{
+		tokens:                  map[tokenKey]authToken{},
+		inflight:                map[tokenKey]*tokenFetch{},
+		subscriptionTenants:     map[string]string{},
+		serviceTags:             map[string]*servicetags.ServiceTags{},
+		resourceLocks:           map[string]*sync.Mutex{},
+		clientId:                clientId,
+		clientSecret:            clientSecret,
+		tenantId:                tenantId,
+		httpClient:              http.DefaultClient,
+		resourceManagerEndpoint: defaultResourceManagerEndpoint,
+		authorityHost:           defaultAuthorityHost,
+		pollInterval:            defaultPollInterval,
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
-		return err
+	for _, opt := range opts {
+		opt(c)
 	}
+	c.httpClient = withLogging(c.httpClient)
+	return c
+}
+
+// AcquireUpdateSlot blocks until fewer updates than the WithMaxConcurrentUpdates limit are running, or ctx is
+// done. The returned function releases the slot once the update completed.
+func (c *Client) AcquireUpdateSlot(ctx context.Context) (release func(), err error) {
+	if c.updateSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.updateSlots <- struct{}{}:
+		return func() { <-c.updateSlots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// LockResource blocks until no other caller holds the lock of the resource id, so that read-modify-write updates of
+// the resource, e.g. of its tags, don't overwrite each other within the provider. The returned function unlocks it.
+func (c *Client) LockResource(id string) (unlock func()) {
+	c.lock.Lock()
+	lock, ok := c.resourceLocks[strings.ToLower(id)]
+	if !ok {
+		lock = &sync.Mutex{}
+		c.resourceLocks[strings.ToLower(id)] = lock
+	}
+	c.lock.Unlock()
+	lock.Lock()
+	return lock.Unlock
+}
 
-	c.authToken.token = tokenResponse.Token
-	c.authToken.expiresAt = time.Now().Unix() + tokenResponse.ExpiresIn
-	return nil
+// SubscriptionID returns the default subscription, empty when not configured.
+func (c *Client) SubscriptionID() string {
+	return c.subscriptionId
 }
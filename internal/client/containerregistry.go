@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"terraform-provider-azurermext/internal/resourceid"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+func (c *Client) ReadContainerRegistry(ctx context.Context, registryId resourceid.ID) (*ContainerRegistryResponse, error) {
+	return Get[ContainerRegistryResponse](ctx, c, registryId)
+}
+
+// UpdateContainerRegistryIpRules replaces the registry's IP rules keeping its current default action.
+func (c *Client) UpdateContainerRegistryIpRules(ctx context.Context, registryId resourceid.ID, defaultAction NetworkRuleAction, rules []string) (*Operation, error) {
+	registryIPRules := make([]ContainerRegistryIpRule, len(rules))
+	for i, ip := range rules {
+		registryIPRules[i] = ContainerRegistryIpRule{Action: NetworkRuleActionAllow, Value: ip}
+	}
+	body := ContainerRegistryResponse{Properties: &ContainerRegistryProperties{
+		NetworkRuleSet: &ContainerRegistryNetworkRuleSet{DefaultAction: defaultAction, IpRules: registryIPRules},
+	}}
+	tflog.Info(ctx, fmt.Sprintf("Updating IP rules to: %v", rules))
+	return BeginPatch(ctx, c, registryId, body)
+}
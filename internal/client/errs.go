@@ -1,8 +1,12 @@
 package client
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"slices"
+	"strings"
 )
 
 // Exported errors
@@ -19,6 +23,90 @@ func (e *NotFoundError) Error() string {
 	return fmt.Sprintf("Resource %s not found", e.id)
 }
 
+// ARMError is an error returned by Azure Resource Manager, either as an error response or as the `error` object
+// of a failed async operation.
+type ARMError struct {
+	ARMErrorDetail
+	// StatusCode is the HTTP status of the failed response. For failed async operations it's the status of the
+	// poll response, which is usually 200.
+	StatusCode int
+	// OperationStatus is set to the terminal status (Failed, Canceled) when the error comes from an async operation.
+	OperationStatus PollResponseStatus
+	// RequestID and CorrelationRequestID are the `x-ms-request-id` and `x-ms-correlation-request-id` headers,
+	// which Azure support asks for.
+	RequestID            string
+	CorrelationRequestID string
+}
+
+// ARMErrorDetail is the ARM error object. Details nest recursively.
+type ARMErrorDetail struct {
+	Code           string                   `json:"code"`
+	Message        string                   `json:"message"`
+	Target         string                   `json:"target,omitempty"`
+	Details        []ARMErrorDetail         `json:"details,omitempty"`
+	AdditionalInfo []ARMErrorAdditionalInfo `json:"additionalInfo,omitempty"`
+}
+
+type ARMErrorAdditionalInfo struct {
+	Type string          `json:"type"`
+	Info json.RawMessage `json:"info,omitempty"`
+}
+
+func (e *ARMError) Error() string {
+	prefix := fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.OperationStatus != "" {
+		prefix = "async operation " + string(e.OperationStatus)
+	}
+	if e.Code != "" {
+		prefix += " (" + e.Code + ")"
+	}
+	if e.Message == "" {
+		return prefix
+	}
+	return prefix + ": " + e.Message
+}
+
+// newARMError decodes the ARM error envelope `{"error": {"code": ..., "message": ...}}`.
+// Some resource providers, CosmosDB among them, return code and message at the top level instead.
+func newARMError(resp *http.Response, body []byte) *ARMError {
+	armErr := &ARMError{
+		StatusCode:           resp.StatusCode,
+		RequestID:            resp.Header.Get("x-ms-request-id"),
+		CorrelationRequestID: resp.Header.Get("x-ms-correlation-request-id"),
+	}
+	var envelope struct {
+		Error *ARMErrorDetail `json:"error"`
+		ARMErrorDetail
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		armErr.Message = string(body)
+		return armErr
+	}
+	if envelope.Error != nil {
+		armErr.ARMErrorDetail = *envelope.Error
+	} else {
+		armErr.ARMErrorDetail = envelope.ARMErrorDetail
+	}
+	if armErr.Code == "" && armErr.Message == "" {
+		armErr.Message = string(body)
+	}
+	return armErr
+}
+
+// newAsyncOperationError builds the error of an async operation which ended in a non successful status.
+func newAsyncOperationError(resp *http.Response, poll *PollResponse) *ARMError {
+	armErr := &ARMError{
+		StatusCode:           resp.StatusCode,
+		OperationStatus:      poll.Status,
+		RequestID:            resp.Header.Get("x-ms-request-id"),
+		CorrelationRequestID: resp.Header.Get("x-ms-correlation-request-id"),
+	}
+	if poll.Error != nil {
+		armErr.ARMErrorDetail = *poll.Error
+	}
+	return armErr
+}
+
 // Helper function to capture errors from deferred functions
 
 func captureErr(errPtr *error, errFunc func() error) {
@@ -28,3 +116,50 @@ func captureErr(errPtr *error, errFunc func() error) {
 	}
 	*errPtr = errors.Join(*errPtr, err)
 }
+
+// AuthenticationError is an error response of the Azure AD token endpoint.
+// It only holds what AAD sent back, never the credentials of the request.
+type AuthenticationError struct {
+	StatusCode int
+	// ErrorCode is the OAuth2 error, e.g. invalid_client.
+	ErrorCode   string
+	Description string
+	// AADSTSCodes are the numeric AADSTS codes, e.g. 7000222 for an expired client secret.
+	AADSTSCodes   []int
+	TraceID       string
+	CorrelationID string
+}
+
+func (e *AuthenticationError) Error() string {
+	if e.ErrorCode == "" {
+		return fmt.Sprintf("failed to request token: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	// The first line of the description holds the actual message, the rest repeats the trace and correlation IDs.
+	description, _, _ := strings.Cut(e.Description, "\r\n")
+	return fmt.Sprintf("failed to request token: %s: %s", e.ErrorCode, description)
+}
+
+// HasAADSTSCode reports whether AAD returned the given AADSTS code.
+func (e *AuthenticationError) HasAADSTSCode(code int) bool {
+	return slices.Contains(e.AADSTSCodes, code)
+}
+
+func newAuthenticationError(resp *http.Response, body []byte) *AuthenticationError {
+	var aadError struct {
+		Error         string `json:"error"`
+		Description   string `json:"error_description"`
+		ErrorCodes    []int  `json:"error_codes"`
+		TraceID       string `json:"trace_id"`
+		CorrelationID string `json:"correlation_id"`
+	}
+	authErr := &AuthenticationError{StatusCode: resp.StatusCode}
+	if err := json.Unmarshal(body, &aadError); err != nil {
+		return authErr
+	}
+	authErr.ErrorCode = aadError.Error
+	authErr.Description = aadError.Description
+	authErr.AADSTSCodes = aadError.ErrorCodes
+	authErr.TraceID = aadError.TraceID
+	authErr.CorrelationID = aadError.CorrelationID
+	return authErr
+}
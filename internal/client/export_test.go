@@ -0,0 +1,11 @@
+package client
+
+import "time"
+
+// TokenRefreshAt returns when the cached token of the client's tenant for scope starts being refreshed.
+func (c *Client) TokenRefreshAt(scope string) (time.Time, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	token, ok := c.tokens[tokenKey{tenantId: c.tenantId, scope: scope}]
+	return token.refreshAt, ok
+}
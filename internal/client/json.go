@@ -1,21 +1,31 @@
 package client
 
+import "encoding/json"
+
 // CosmosDBResponse
 
 type CosmosDBResponse struct {
 	ID         string              `json:"id"`
+	Name       string              `json:"name,omitempty"`
+	Location   string              `json:"location,omitempty"`
+	Tags       map[string]string   `json:"tags,omitempty"`
 	Properties *CosmosDBProperties `json:"properties"`
 }
 
 type CosmosDBProperties struct {
-	IpRules             []CosmosDBIpRule            `json:"ipRules"`
-	PublicNetworkAccess cosmosDBPublicNetworkAccess `json:"publicNetworkAccess"`
+	IpRules             []CosmosDBIpRule             `json:"ipRules"`
+	VirtualNetworkRules []CosmosDBVirtualNetworkRule `json:"virtualNetworkRules,omitempty"`
+	PublicNetworkAccess cosmosDBPublicNetworkAccess  `json:"publicNetworkAccess"`
 }
 
 type CosmosDBIpRule struct {
 	IpAddressOrRange string `json:"ipAddressOrRange"`
 }
 
+type CosmosDBVirtualNetworkRule struct {
+	ID string `json:"id"`
+}
+
 type cosmosDBPublicNetworkAccess string
 
 const (
@@ -31,6 +41,7 @@ func (a cosmosDBPublicNetworkAccess) IsEnabled() bool {
 
 type PollResponse struct {
 	Status PollResponseStatus `json:"status"`
+	Error  *ARMErrorDetail    `json:"error,omitempty"`
 }
 
 type PollResponseStatus string
@@ -41,12 +52,76 @@ const (
 	PollResponseStatusInProgress PollResponseStatus = "InProgress"
 	PollResponseStatusEnqueued   PollResponseStatus = "Enqueued"
 	PollResponseStatusDequeued   PollResponseStatus = "Dequeued"
+	PollResponseStatusCanceled   PollResponseStatus = "Canceled"
 )
 
+// IsPending reports whether the operation is still running. Resource providers use various non-terminal statuses
+// (InProgress, Enqueued, Dequeued, Accepted, Running, ...), so anything which isn't terminal is pending.
 func (s PollResponseStatus) IsPending() bool {
-	return s == PollResponseStatusInProgress || s == PollResponseStatusEnqueued || s == PollResponseStatusDequeued
+	return s != "" && s != PollResponseStatusSucceeded && s != PollResponseStatusFailed && s != PollResponseStatusCanceled
 }
 
 func (s PollResponseStatus) IsSuccess() bool {
 	return s == PollResponseStatusSucceeded
 }
+
+// Network rules shared by Container Registry, Event Hubs and Service Bus
+
+type NetworkRuleAction string
+
+const (
+	NetworkRuleActionAllow NetworkRuleAction = "Allow"
+	NetworkRuleActionDeny  NetworkRuleAction = "Deny"
+)
+
+// ContainerRegistryResponse
+
+type ContainerRegistryResponse struct {
+	ID         string                       `json:"id,omitempty"`
+	Properties *ContainerRegistryProperties `json:"properties"`
+}
+
+type ContainerRegistryProperties struct {
+	NetworkRuleSet      *ContainerRegistryNetworkRuleSet `json:"networkRuleSet,omitempty"`
+	PublicNetworkAccess publicNetworkAccess              `json:"publicNetworkAccess,omitempty"`
+}
+
+type ContainerRegistryNetworkRuleSet struct {
+	DefaultAction NetworkRuleAction         `json:"defaultAction"`
+	IpRules       []ContainerRegistryIpRule `json:"ipRules"`
+}
+
+type ContainerRegistryIpRule struct {
+	Action NetworkRuleAction `json:"action"`
+	Value  string            `json:"value"`
+}
+
+// NamespaceNetworkRuleSetResponse (Event Hubs and Service Bus)
+
+type NamespaceNetworkRuleSetResponse struct {
+	ID         string                             `json:"id,omitempty"`
+	Properties *NamespaceNetworkRuleSetProperties `json:"properties"`
+}
+
+type NamespaceNetworkRuleSetProperties struct {
+	TrustedServiceAccessEnabled *bool               `json:"trustedServiceAccessEnabled,omitempty"`
+	DefaultAction               NetworkRuleAction   `json:"defaultAction,omitempty"`
+	VirtualNetworkRules         json.RawMessage     `json:"virtualNetworkRules,omitempty"`
+	IpRules                     []NamespaceIpRule   `json:"ipRules"`
+	PublicNetworkAccess         publicNetworkAccess `json:"publicNetworkAccess,omitempty"`
+}
+
+type NamespaceIpRule struct {
+	IpMask string            `json:"ipMask"`
+	Action NetworkRuleAction `json:"action"`
+}
+
+type publicNetworkAccess string
+
+const (
+	publicNetworkAccessEnabled publicNetworkAccess = "Enabled"
+)
+
+func (a publicNetworkAccess) IsEnabled() bool {
+	return a == publicNetworkAccessEnabled
+}
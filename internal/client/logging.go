@@ -0,0 +1,228 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// httpLogSubsystem is the tflog subsystem of the HTTP traces. Its level can be set on its own with
+// TF_LOG_PROVIDER_AZURERMEXT_HTTP, e.g. to get bodies at TRACE while keeping the rest of the provider at DEBUG.
+const httpLogSubsystem = "http"
+
+// maxLoggedBodySize bounds the size of the bodies written to the logs.
+const maxLoggedBodySize = 64 * 1024
+
+const redacted = "REDACTED"
+
+var (
+	// secretFormFields are the form-encoded fields redacted from token request bodies.
+	secretFormFields = []string{"client_secret", "client_assertion", "refresh_token", "assertion", "password"}
+	// secretJSONFields are the JSON fields redacted from token response bodies.
+	secretJSONFields = []string{"access_token", "refresh_token", "id_token"}
+	// jwtRegexp matches anything looking like a JWT that would slip through, e.g. in an error message.
+	jwtRegexp = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+)
+
+// loggingTransport traces every request with tflog: method, URL, status, duration and Azure request IDs at DEBUG,
+// headers and bodies at TRACE. Credentials are redacted so that the logs are safe to share.
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+func newLoggingTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &loggingTransport{next: next}
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := tflog.NewSubsystem(req.Context(), httpLogSubsystem,
+		tflog.WithLevelFromEnv("TF_LOG_PROVIDER_AZURERMEXT", httpLogSubsystem),
+		tflog.WithRootFields(),
+	)
+	ctx = tflog.SubsystemMaskAllFieldValuesRegexes(ctx, httpLogSubsystem, jwtRegexp)
+	ctx = tflog.SubsystemMaskMessageRegexes(ctx, httpLogSubsystem, jwtRegexp)
+
+	fields := map[string]interface{}{
+		"http_method": req.Method,
+		"http_url":    redactURL(req.URL),
+	}
+	tflog.SubsystemTrace(ctx, httpLogSubsystem, "Sending HTTP request", mergeFields(fields, map[string]interface{}{
+		"http_request_headers": redactHeaders(req.Header),
+		"http_request_body":    requestBodyForLog(req),
+	}))
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	fields["http_duration_ms"] = time.Since(start).Milliseconds()
+	if err != nil {
+		tflog.SubsystemDebug(ctx, httpLogSubsystem, "HTTP request failed", mergeFields(fields, map[string]interface{}{
+			"error": err.Error(),
+		}))
+		return nil, err
+	}
+
+	fields["http_status"] = resp.StatusCode
+	for field, header := range map[string]string{
+		"x_ms_request_id":             "x-ms-request-id",
+		"x_ms_correlation_request_id": "x-ms-correlation-request-id",
+		"x_ms_ests_server":            "x-ms-ests-server",
+	} {
+		if value := resp.Header.Get(header); value != "" {
+			fields[field] = value
+		}
+	}
+	tflog.SubsystemDebug(ctx, httpLogSubsystem, "Received HTTP response", fields)
+
+	// The body is logged once the caller read or closed it, so that it's neither buffered nor delayed.
+	responseFields := mergeFields(fields, map[string]interface{}{"http_response_headers": redactHeaders(resp.Header)})
+	contentType := resp.Header.Get("Content-Type")
+	resp.Body = &loggedBody{ReadCloser: resp.Body, log: func(body []byte) {
+		responseFields["http_response_body"] = truncateBody(redactBody(contentType, body))
+		tflog.SubsystemTrace(ctx, httpLogSubsystem, "HTTP response body", responseFields)
+	}}
+	return resp, nil
+}
+
+// loggedBody copies the first maxLoggedBodySize bytes read from a body and hands them to log at EOF or on Close,
+// whichever comes first.
+type loggedBody struct {
+	io.ReadCloser
+	log    func(body []byte)
+	copied bytes.Buffer
+	once   sync.Once
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := maxLoggedBodySize + 1 - b.copied.Len(); room > 0 {
+		b.copied.Write(p[:min(n, room)])
+	}
+	if err == io.EOF {
+		b.once.Do(func() { b.log(b.copied.Bytes()) })
+	}
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	b.once.Do(func() { b.log(b.copied.Bytes()) })
+	return b.ReadCloser.Close()
+}
+
+func requestBodyForLog(req *http.Request) string {
+	if req.Body == nil || req.GetBody == nil {
+		return ""
+	}
+	reader, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer reader.Close()
+	body, err := io.ReadAll(io.LimitReader(reader, maxLoggedBodySize+1))
+	if err != nil {
+		return ""
+	}
+	return truncateBody(redactBody(req.Header.Get("Content-Type"), body))
+}
+
+// redactBody masks the secrets of form-encoded token requests and JSON token responses.
+func redactBody(contentType string, body []byte) string {
+	switch {
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return redacted
+		}
+		for _, field := range secretFormFields {
+			if values.Has(field) {
+				values.Set(field, redacted)
+			}
+		}
+		return values.Encode()
+	case strings.HasPrefix(contentType, "application/json"):
+		var object map[string]json.RawMessage
+		if json.Unmarshal(body, &object) != nil {
+			return string(body)
+		}
+		changed := false
+		for _, field := range secretJSONFields {
+			if _, ok := object[field]; ok {
+				object[field] = json.RawMessage(`"` + redacted + `"`)
+				changed = true
+			}
+		}
+		if !changed {
+			return string(body)
+		}
+		redactedBody, _ := json.Marshal(object)
+		return string(redactedBody)
+	}
+	return string(body)
+}
+
+func redactHeaders(headers http.Header) map[string]string {
+	logged := make(map[string]string, len(headers))
+	for name, values := range headers {
+		switch http.CanonicalHeaderKey(name) {
+		case "Authorization", "X-Ms-Authorization-Auxiliary", "Cookie", "Set-Cookie":
+			logged[name] = redacted
+		default:
+			logged[name] = strings.Join(values, ", ")
+		}
+	}
+	return logged
+}
+
+// redactURL masks query parameters which may carry credentials, e.g. SAS signatures in async operation URLs.
+func redactURL(u *url.URL) string {
+	query := u.Query()
+	changed := false
+	for _, param := range []string{"sig", "code", "client_secret"} {
+		if query.Has(param) {
+			query.Set(param, redacted)
+			changed = true
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+	redactedUrl := *u
+	redactedUrl.RawQuery = query.Encode()
+	return redactedUrl.String()
+}
+
+func truncateBody(body string) string {
+	if len(body) <= maxLoggedBodySize {
+		return body
+	}
+	return body[:maxLoggedBodySize] + "...(truncated)"
+}
+
+func mergeFields(base, extra map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(extra))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+// withLogging returns a copy of the HTTP client with its transport wrapped by loggingTransport.
+// The caller's client is left untouched since it may be shared, e.g. by tests.
+func withLogging(httpClient *http.Client) *http.Client {
+	logged := *httpClient
+	logged.Transport = newLoggingTransport(httpClient.Transport)
+	return &logged
+}
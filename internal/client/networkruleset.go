@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"terraform-provider-azurermext/internal/resourceid"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Event Hubs and Service Bus namespaces share the same `networkRuleSets/default` child resource shape,
+// only the api-version differs between the two resource providers.
+
+func namespaceNetworkRuleSetId(namespaceId resourceid.ID) resourceid.ID {
+	return namespaceId.Child("networkRuleSets", "default")
+}
+
+func (c *Client) ReadNamespaceNetworkRuleSet(ctx context.Context, namespaceId resourceid.ID) (*NamespaceNetworkRuleSetResponse, error) {
+	return Get[NamespaceNetworkRuleSetResponse](ctx, c, namespaceNetworkRuleSetId(namespaceId))
+}
+
+// UpdateNamespaceNetworkRuleSet PUTs the rule set back with its IP rules replaced. The rule set is a full
+// resource, so the current value must be passed in to preserve virtual network rules and the default action.
+func (c *Client) UpdateNamespaceNetworkRuleSet(ctx context.Context, namespaceId resourceid.ID, current *NamespaceNetworkRuleSetResponse, rules []string) (*Operation, error) {
+	if current == nil || current.Properties == nil {
+		return nil, fmt.Errorf("network rule set of namespace %s has no properties", namespaceId)
+	}
+	namespaceIPRules := make([]NamespaceIpRule, len(rules))
+	for i, ip := range rules {
+		namespaceIPRules[i] = NamespaceIpRule{IpMask: ip, Action: NetworkRuleActionAllow}
+	}
+	properties := *current.Properties
+	properties.IpRules = namespaceIPRules
+	body := NamespaceNetworkRuleSetResponse{Properties: &properties}
+	tflog.Info(ctx, fmt.Sprintf("Updating IP rules to: %v", rules))
+	return BeginPut(ctx, c, namespaceNetworkRuleSetId(namespaceId), body)
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// page is a page of an ARM list operation. The next page, if any, is read from nextLink.
+type page[T any] struct {
+	Value    []T    `json:"value"`
+	NextLink string `json:"nextLink"`
+}
+
+// Pager reads the pages of an ARM list operation one at a time, following nextLink until a page has none.
+type Pager[T any] struct {
+	client *Client
+	url    string
+}
+
+// NewPager returns a pager over the list operation at url, which must include its api-version.
+func NewPager[T any](c *Client, url string) *Pager[T] {
+	return &Pager[T]{client: c, url: url}
+}
+
+// More reports whether there are pages left to read.
+func (p *Pager[T]) More() bool {
+	return p.url != ""
+}
+
+// NextPage reads the next page and returns its items.
+func (p *Pager[T]) NextPage(ctx context.Context) ([]T, error) {
+	_, body, err := p.client.do(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result page[T]
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	p.url = result.NextLink
+	return result.Value, nil
+}
+
+// ListAll reads every page of the list operation at url and returns all the items.
+func ListAll[T any](ctx context.Context, c *Client, url string) ([]T, error) {
+	var items []T
+	pager := NewPager[T](c, url)
+	for pager.More() {
+		values, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, values...)
+	}
+	return items, nil
+}
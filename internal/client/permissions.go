@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"terraform-provider-azurermext/internal/resourceid"
+)
+
+const permissionsApiVersion = "2022-04-01"
+
+// Permission is one entry of the permissions the caller has on a scope, as returned by
+// `providers/Microsoft.Authorization/permissions`. Every role assignment contributes an entry.
+type Permission struct {
+	Actions        []string `json:"actions"`
+	NotActions     []string `json:"notActions"`
+	DataActions    []string `json:"dataActions"`
+	NotDataActions []string `json:"notDataActions"`
+}
+
+// WithPermissionCheck makes PermissionCheckEnabled report true, so that resources verify the principal's
+// permissions at plan time.
+func WithPermissionCheck(enabled bool) Option {
+	return func(c *Client) {
+		c.permissionCheck = enabled
+	}
+}
+
+// PermissionCheckEnabled reports whether resources should call MissingPermissions before planning changes.
+func (c *Client) PermissionCheckEnabled() bool {
+	return c.permissionCheck
+}
+
+// ReadPermissions returns the permissions the client's principal has on scope.
+func (c *Client) ReadPermissions(ctx context.Context, scope resourceid.ID) ([]Permission, error) {
+	url := c.resourceManagerEndpoint + scope.String() + "/providers/Microsoft.Authorization/permissions?api-version=" + permissionsApiVersion
+	return ListAll[Permission](ctx, c, url)
+}
+
+// MissingPermissions returns the actions, e.g. "Microsoft.DocumentDB/databaseAccounts/write", that the client's
+// principal isn't granted on scope.
+func (c *Client) MissingPermissions(ctx context.Context, scope resourceid.ID, actions ...string) ([]string, error) {
+	permissions, err := c.ReadPermissions(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, action := range actions {
+		if !isActionGranted(permissions, action) {
+			missing = append(missing, action)
+		}
+	}
+	return missing, nil
+}
+
+// isActionGranted reports whether an entry grants action without excluding it through its NotActions.
+// Entries are evaluated on their own since NotActions only restrict the role they belong to.
+func isActionGranted(permissions []Permission, action string) bool {
+	for _, permission := range permissions {
+		if matchesAnyAction(permission.Actions, action) && !matchesAnyAction(permission.NotActions, action) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAnyAction(patterns []string, action string) bool {
+	for _, pattern := range patterns {
+		if matchAction(pattern, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAction matches an action against a role definition pattern, where `*` matches any sequence of characters
+// including `/`, e.g. "Microsoft.DocumentDB/*" or "*/read". Actions are case-insensitive.
+func matchAction(pattern, action string) bool {
+	pattern, action = strings.ToLower(pattern), strings.ToLower(action)
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == action
+	}
+	if !strings.HasPrefix(action, parts[0]) {
+		return false
+	}
+	action = action[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(action, part)
+		if i < 0 {
+			return false
+		}
+		action = action[i+len(part):]
+	}
+	return strings.HasSuffix(action, parts[len(parts)-1])
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const resourceGraphApiVersion = "2022-10-01"
+
+// resourceGraphPageSize is the largest page Resource Graph returns.
+const resourceGraphPageSize = 1000
+
+// ResourceGraphQuery is a KQL query of Azure Resource Graph and the scopes it runs on. Without subscriptions nor
+// management groups, it runs on every subscription of the tenant the principal can read.
+type ResourceGraphQuery struct {
+	Query            string   `json:"query"`
+	Subscriptions    []string `json:"subscriptions,omitempty"`
+	ManagementGroups []string `json:"managementGroups,omitempty"`
+}
+
+type resourceGraphRequest struct {
+	ResourceGraphQuery
+	Options resourceGraphQueryOptions `json:"options"`
+}
+
+type resourceGraphQueryOptions struct {
+	SkipToken    string `json:"$skipToken,omitempty"`
+	Top          int    `json:"$top"`
+	ResultFormat string `json:"resultFormat"`
+}
+
+type resourceGraphResponse struct {
+	TotalRecords int64             `json:"totalRecords"`
+	Data         []json.RawMessage `json:"data"`
+	SkipToken    string            `json:"$skipToken"`
+}
+
+// QueryResourceGraph runs a query and returns every row, as JSON objects, following `$skipToken` until the last page.
+func (c *Client) QueryResourceGraph(ctx context.Context, query ResourceGraphQuery) ([]json.RawMessage, error) {
+	url := c.resourceManagerEndpoint + "/providers/Microsoft.ResourceGraph/resources?api-version=" + resourceGraphApiVersion
+	request := resourceGraphRequest{query, resourceGraphQueryOptions{Top: resourceGraphPageSize, ResultFormat: "objectArray"}}
+	rows := []json.RawMessage{}
+	for {
+		tflog.Debug(ctx, fmt.Sprintf("Querying Resource Graph from row %d", len(rows)))
+		_, body, err := c.do(ctx, http.MethodPost, url, request)
+		if err != nil {
+			return nil, err
+		}
+		var page resourceGraphResponse
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, err
+		}
+		rows = append(rows, page.Data...)
+		if page.SkipToken == "" {
+			return rows, nil
+		}
+		request.Options.SkipToken = page.SkipToken
+	}
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"terraform-provider-azurermext/internal/servicetags"
+)
+
+const serviceTagsApiVersion = "2024-05-01"
+
+// ReadServiceTags returns the service tags of the cloud the subscription belongs to. location only selects the
+// version of the tags, every region's tags are returned whatever its value. Subscription defaults to the client's
+// and location accepts display names such as "West Europe".
+//
+// The tags are cached for the lifetime of the client since they change weekly at most and weigh several MB.
+func (c *Client) ReadServiceTags(ctx context.Context, subscriptionId, location string) (*servicetags.ServiceTags, error) {
+	if subscriptionId == "" {
+		subscriptionId = c.subscriptionId
+	}
+	if subscriptionId == "" {
+		return nil, errors.New("reading service tags requires a subscription ID, none is configured")
+	}
+	location = strings.ToLower(strings.ReplaceAll(location, " ", ""))
+	key := strings.ToLower(subscriptionId) + "/" + location
+
+	c.lock.Lock()
+	tags, ok := c.serviceTags[key]
+	c.lock.Unlock()
+	if ok {
+		return tags, nil
+	}
+
+	url := c.resourceManagerEndpoint + "/subscriptions/" + subscriptionId +
+		"/providers/Microsoft.Network/locations/" + location + "/serviceTags?api-version=" + serviceTagsApiVersion
+	_, body, err := c.do(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	tags = &servicetags.ServiceTags{}
+	if err := json.Unmarshal(body, tags); err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	c.serviceTags[key] = tags
+	c.lock.Unlock()
+	return tags, nil
+}
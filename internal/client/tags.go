@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"terraform-provider-azurermext/internal/resourceid"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const tagsApiVersion = "2021-04-01"
+
+// TagsOperation is how UpdateTags changes the tags of a resource.
+type TagsOperation string
+
+const (
+	// TagsOperationMerge adds the given tags, replacing the values of existing ones.
+	TagsOperationMerge TagsOperation = "Merge"
+	// TagsOperationDelete removes the given tags.
+	TagsOperationDelete TagsOperation = "Delete"
+)
+
+type tagsPatch struct {
+	Operation  TagsOperation      `json:"operation"`
+	Properties tagsPatchResources `json:"properties"`
+}
+
+type tagsPatchResources struct {
+	Tags map[string]string `json:"tags"`
+}
+
+// UpdateTags changes some tags of a resource through the `Microsoft.Resources/tags` API, leaving its other tags and
+// its properties untouched. Unlike a PATCH of the resource it doesn't start a long running update.
+func (c *Client) UpdateTags(ctx context.Context, scope resourceid.ID, operation TagsOperation, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	url := c.resourceManagerEndpoint + scope.String() + "/providers/Microsoft.Resources/tags/default?api-version=" + tagsApiVersion
+	tflog.Info(ctx, fmt.Sprintf("Updating tags (%s): %v", operation, tags))
+	_, _, err := c.do(ctx, http.MethodPatch, url, tagsPatch{Operation: operation, Properties: tagsPatchResources{Tags: tags}})
+	return err
+}
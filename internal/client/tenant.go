@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// MaxAuxiliaryTenants is the number of auxiliary tokens ARM accepts in `x-ms-authorization-auxiliary`.
+const MaxAuxiliaryTenants = 3
+
+var (
+	subscriptionPathRegexp = regexp.MustCompile(`(?i)/subscriptions/([^/?]+)`)
+	// authorizationUriRegexp extracts the authority from ARM's challenge, e.g.
+	// `Bearer authorization_uri="https://login.windows.net/{tenantId}", error="invalid_token", ...`.
+	authorizationUriRegexp = regexp.MustCompile(`authorization_uri="([^"]+)"`)
+)
+
+// tenantFor returns the tenant whose token authenticates a request to rawUrl: the tenant learnt for the
+// subscription the URL targets, or the client's own tenant.
+func (c *Client) tenantFor(rawUrl string) string {
+	subscriptionId := subscriptionFromURL(rawUrl)
+	if subscriptionId == "" {
+		return c.tenantId
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if tenantId, ok := c.subscriptionTenants[subscriptionId]; ok {
+		return tenantId
+	}
+	return c.tenantId
+}
+
+// learnTenant records that the subscription of rawUrl belongs to tenantId, if tenantId is a configured tenant.
+// It reports whether the request should be sent again with a token of tenantId.
+func (c *Client) learnTenant(ctx context.Context, rawUrl, tenantId string) bool {
+	subscriptionId := subscriptionFromURL(rawUrl)
+	if subscriptionId == "" || !c.isConfiguredTenant(tenantId) {
+		tflog.Debug(ctx, "Subscription "+subscriptionId+" belongs to tenant "+tenantId+", which isn't configured as an auxiliary tenant")
+		return false
+	}
+	tflog.Debug(ctx, "Subscription "+subscriptionId+" belongs to tenant "+tenantId)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.subscriptionTenants[subscriptionId] = tenantId
+	return true
+}
+
+func (c *Client) isConfiguredTenant(tenantId string) bool {
+	if strings.EqualFold(tenantId, c.tenantId) {
+		return true
+	}
+	for _, auxiliary := range c.auxiliaryTenantIds {
+		if strings.EqualFold(tenantId, auxiliary) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorize sets the `Authorization` header with a token of tenantId. When tenantId isn't the client's own tenant,
+// it also sets the `x-ms-authorization-auxiliary` header with tokens of the other configured tenants, which ARM
+// needs for cross-tenant operations. An auxiliary tenant whose token can't be fetched is skipped, the request may
+// not need it.
+func (c *Client) authorize(ctx context.Context, req *http.Request, tenantId string) error {
+	token, err := c.getTokenForTenant(ctx, tenantId, ManagementScope)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if len(c.auxiliaryTenantIds) == 0 || strings.EqualFold(tenantId, c.tenantId) {
+		return nil
+	}
+
+	var auxiliary []string
+	for _, other := range append([]string{c.tenantId}, c.auxiliaryTenantIds...) {
+		if strings.EqualFold(other, tenantId) || len(auxiliary) == MaxAuxiliaryTenants {
+			continue
+		}
+		token, err := c.getTokenForTenant(ctx, other, ManagementScope)
+		if err != nil {
+			tflog.Warn(ctx, "Skipping auxiliary tenant "+other+", its token can't be fetched: "+err.Error())
+			continue
+		}
+		auxiliary = append(auxiliary, "Bearer "+token)
+	}
+	if len(auxiliary) != 0 {
+		req.Header.Set("x-ms-authorization-auxiliary", strings.Join(auxiliary, ", "))
+	}
+	return nil
+}
+
+// challengedTenant returns the tenant ARM asks a token from in the `WWW-Authenticate` header of a 401, which it
+// sends when the token was issued by another tenant than the one of the subscription.
+func challengedTenant(resp *http.Response) string {
+	match := authorizationUriRegexp.FindStringSubmatch(resp.Header.Get("WWW-Authenticate"))
+	if match == nil {
+		return ""
+	}
+	authority, err := url.Parse(match[1])
+	if err != nil {
+		return ""
+	}
+	tenantId, _, _ := strings.Cut(strings.TrimPrefix(authority.Path, "/"), "/")
+	return tenantId
+}
+
+func subscriptionFromURL(rawUrl string) string {
+	match := subscriptionPathRegexp.FindStringSubmatch(rawUrl)
+	if match == nil {
+		return ""
+	}
+	return strings.ToLower(match[1])
+}
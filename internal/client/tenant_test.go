@@ -0,0 +1,76 @@
+package client_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"terraform-provider-azurermext/internal/client"
+	"terraform-provider-azurermext/internal/resourceid"
+	"terraform-provider-azurermext/internal/testing/fakearm"
+	"testing"
+)
+
+func TestCrossTenantRequests(t *testing.T) {
+	const (
+		auxiliaryTenant = "00000000-0000-0000-0000-00000000cccc"
+		// unknownTenant is an auxiliary tenant the service principal can't get tokens from.
+		unknownTenant       = "00000000-0000-0000-0000-00000000dddd"
+		foreignSubscription = "00000000-0000-0000-0000-000000000002"
+		homeSubscription    = "00000000-0000-0000-0000-000000000001"
+	)
+	server := fakearm.New(t)
+	server.SetSubscriptionTenant(foreignSubscription, auxiliaryTenant)
+	foreignId := testParseId(t, "/subscriptions/"+foreignSubscription+"/resourceGroups/rg/providers/Microsoft.DocumentDB/databaseAccounts/foreign")
+	homeId := testParseId(t, "/subscriptions/"+homeSubscription+"/resourceGroups/rg/providers/Microsoft.DocumentDB/databaseAccounts/home")
+	server.AddCosmosDBAccount(foreignId.String(), []string{"1.1.1.1"})
+	server.AddCosmosDBAccount(homeId.String(), []string{"1.1.1.1"})
+	c := client.New(fakearm.ClientID, fakearm.ClientSecret, fakearm.TenantID,
+		append(server.ClientOptions(), client.WithAuxiliaryTenantIDs(unknownTenant, auxiliaryTenant))...)
+
+	// The first request to the foreign subscription is challenged, then sent again with a token of its tenant.
+	for range 2 {
+		if _, err := c.ReadCosmosDB(context.Background(), foreignId); err != nil {
+			t.Fatalf("ReadCosmosDB() failed: %v", err)
+		}
+	}
+	if _, err := c.ReadCosmosDB(context.Background(), homeId); err != nil {
+		t.Fatalf("ReadCosmosDB() failed: %v", err)
+	}
+
+	type sent struct {
+		tenant    string
+		auxiliary []string
+	}
+	want := map[string][]sent{
+		foreignId.String(): {
+			{tenant: fakearm.TenantID},
+			// The token of the home tenant goes along as auxiliary, the one of the unknown tenant is skipped.
+			{tenant: auxiliaryTenant, auxiliary: []string{fakearm.TenantID}},
+			{tenant: auxiliaryTenant, auxiliary: []string{fakearm.TenantID}},
+		},
+		// Requests to the home tenant only carry its token.
+		homeId.String(): {{tenant: fakearm.TenantID}},
+	}
+	got := map[string][]sent{}
+	for _, request := range server.Requests() {
+		if strings.Contains(request.Path, "/subscriptions/") {
+			got[request.Path] = append(got[request.Path], sent{tenant: request.Tenant, auxiliary: request.AuxiliaryTenants})
+		}
+	}
+	for id, wantSent := range want {
+		if !slices.EqualFunc(got[id], wantSent, func(a, b sent) bool {
+			return a.tenant == b.tenant && slices.Equal(a.auxiliary, b.auxiliary)
+		}) {
+			t.Errorf("requests to %s were sent with the tokens %+v, want %+v", id, got[id], wantSent)
+		}
+	}
+}
+
+func testParseId(t *testing.T, id string) resourceid.ID {
+	t.Helper()
+	parsed, err := resourceid.Parse(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// ManagementScope is the scope of Azure Resource Manager tokens.
+const ManagementScope = "https://management.core.windows.net//.default"
+
+const (
+	// tokenExpiryMargin is how long before its expiry a token stops being handed out, so that it doesn't expire
+	// in flight.
+	tokenExpiryMargin = 2 * time.Minute
+	// tokenRequestTimeout bounds a token request. The request outlives the caller that started it since other
+	// callers may be waiting on it, see tokenFetch.
+	tokenRequestTimeout = time.Minute
+)
+
+type authToken struct {
+	token string
+	// refreshAt is when a background refresh starts while the token is still handed out.
+	refreshAt time.Time
+	expiresAt time.Time
+	// extExpiresAt is the extended lifetime AAD grants for resilience: the token is only used past expiresAt when
+	// AAD itself can't be reached.
+	extExpiresAt time.Time
+}
+
+// tokenKey identifies a cached token: tokens are issued by a tenant for a scope.
+type tokenKey struct {
+	tenantId string
+	scope    string
+}
+
+// tokenFetch is a token request shared by every caller asking for the same token while it's in flight.
+type tokenFetch struct {
+	done  chan struct{}
+	token authToken
+	err   error
+}
+
+// GetToken returns an Azure Resource Manager token.
+func (c *Client) GetToken(ctx context.Context) (string, error) {
+	return c.GetTokenForScope(ctx, ManagementScope)
+}
+
+// GetTokenForScope returns a token for any audience the service principal can get tokens for, e.g. a data-plane
+// scope such as "https://cosmos.azure.com/.default". Tokens are cached per scope and refreshed in the background
+// once past half of their lifetime. Concurrent callers share a single token request.
+func (c *Client) GetTokenForScope(ctx context.Context, scope string) (string, error) {
+	return c.getTokenForTenant(ctx, c.tenantId, scope)
+}
+
+// getTokenForTenant returns a token issued by tenantId, which is the provider's tenant or one of its auxiliary
+// tenants.
+func (c *Client) getTokenForTenant(ctx context.Context, tenantId, scope string) (string, error) {
+	key := tokenKey{tenantId: tenantId, scope: scope}
+	c.lock.Lock()
+	cached, ok := c.tokens[key]
+	now := time.Now()
+	if ok && now.Before(cached.expiresAt.Add(-tokenExpiryMargin)) {
+		if now.After(cached.refreshAt) {
+			c.startTokenFetch(ctx, key)
+		}
+		c.lock.Unlock()
+		return cached.token, nil
+	}
+	fetch := c.startTokenFetch(ctx, key)
+	c.lock.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-fetch.done:
+	}
+	if fetch.err != nil {
+		if ok && isTransientTokenError(fetch.err) && now.Before(cached.extExpiresAt) {
+			tflog.Warn(ctx, "Azure AD is unreachable, using a token within its extended lifetime: "+fetch.err.Error())
+			return cached.token, nil
+		}
+		return "", fetch.err
+	}
+	return fetch.token.token, nil
+}
+
+// startTokenFetch starts a token request for key unless one is already in flight. c.lock must be held.
+func (c *Client) startTokenFetch(ctx context.Context, key tokenKey) *tokenFetch {
+	if fetch, ok := c.inflight[key]; ok {
+		return fetch
+	}
+	fetch := &tokenFetch{done: make(chan struct{})}
+	c.inflight[key] = fetch
+
+	// The request is detached from the caller's cancellation, so a caller giving up doesn't fail the others,
+	// but keeps its values for logging.
+	fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tokenRequestTimeout)
+	go func() {
+		defer cancel()
+		var token authToken
+		var err error
+		if c.tokenCache != nil {
+			cacheKey := tokenCacheKey{tenantId: key.tenantId, clientId: c.clientId, authority: c.authorityHost, scope: key.scope}
+			token, err = c.tokenCache.getOrRequest(fetchCtx, cacheKey, func() (authToken, error) {
+				return c.requestToken(fetchCtx, key)
+			})
+		} else {
+			token, err = c.requestToken(fetchCtx, key)
+		}
+
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		if err == nil {
+			c.tokens[key] = token
+		} else {
+			tflog.Debug(fetchCtx, "Token request failed: "+err.Error())
+		}
+		delete(c.inflight, key)
+		fetch.token, fetch.err = token, err
+		close(fetch.done)
+	}()
+	return fetch
+}
+
+// requestToken requests a token with the client credentials flow.
+func (c *Client) requestToken(ctx context.Context, key tokenKey) (_ authToken, cErr error) {
+	reqBody := url.Values{}
+	reqBody.Set("grant_type", "client_credentials")
+	reqBody.Set("client_id", c.clientId)
+	reqBody.Set("client_secret", c.clientSecret)
+	reqBody.Set("scope", key.scope)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		fmt.Sprintf("%s/%s/oauth2/v2.0/token", c.authorityHost, key.tenantId),
+		strings.NewReader(reqBody.Encode()),
+	)
+	if err != nil {
+		return authToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	issuedAt := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return authToken{}, err
+	}
+	defer captureErr(&cErr, resp.Body.Close)
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return authToken{}, newAuthenticationError(resp, respBody)
+	}
+
+	var tokenResponse struct {
+		Token        string `json:"access_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+		ExtExpiresIn int64  `json:"ext_expires_in"`
+		RefreshIn    int64  `json:"refresh_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return authToken{}, err
+	}
+
+	lifetime := time.Duration(tokenResponse.ExpiresIn) * time.Second
+	token := authToken{
+		token:        tokenResponse.Token,
+		expiresAt:    issuedAt.Add(lifetime),
+		extExpiresAt: issuedAt.Add(lifetime),
+		refreshAt:    issuedAt.Add(lifetime / 2),
+	}
+	if tokenResponse.ExtExpiresIn > tokenResponse.ExpiresIn {
+		token.extExpiresAt = issuedAt.Add(time.Duration(tokenResponse.ExtExpiresIn) * time.Second)
+	}
+	// AAD may tell when to refresh, which takes precedence over the half-life default.
+	if tokenResponse.RefreshIn > 0 && tokenResponse.RefreshIn < tokenResponse.ExpiresIn {
+		token.refreshAt = issuedAt.Add(time.Duration(tokenResponse.RefreshIn) * time.Second)
+	}
+	return token, nil
+}
+
+// isTransientTokenError reports whether a token request failed because AAD couldn't be reached or was unhealthy,
+// as opposed to AAD rejecting the credentials.
+func isTransientTokenError(err error) bool {
+	var authErr *AuthenticationError
+	if errors.As(err, &authErr) {
+		return authErr.StatusCode == http.StatusTooManyRequests || authErr.StatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"terraform-provider-azurermext/internal/client"
+	"terraform-provider-azurermext/internal/testing/fakearm"
+	"testing"
+	"time"
+)
+
+func testClient(server *fakearm.Server) *client.Client {
+	return client.New(fakearm.ClientID, fakearm.ClientSecret, fakearm.TenantID, server.ClientOptions()...)
+}
+
+// testTokenRequests counts the token requests received by server.
+func testTokenRequests(server *fakearm.Server) int {
+	count := 0
+	for _, request := range server.Requests() {
+		if strings.HasSuffix(request.Path, "/oauth2/v2.0/token") {
+			count++
+		}
+	}
+	return count
+}
+
+func TestGetTokenConcurrentCallers(t *testing.T) {
+	server := fakearm.New(t)
+	server.SetTokenDelay(100 * time.Millisecond)
+	c := testClient(server)
+
+	tokens := make([]string, 10)
+	errs := make([]error, len(tokens))
+	var wg sync.WaitGroup
+	for i := range tokens {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tokens[i], errs[i] = c.GetToken(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	for i := range tokens {
+		if errs[i] != nil {
+			t.Fatalf("GetToken() failed: %v", errs[i])
+		}
+		if tokens[i] == "" || tokens[i] != tokens[0] {
+			t.Errorf("GetToken() = %q, want the token %q every caller shares", tokens[i], tokens[0])
+		}
+	}
+	if got := testTokenRequests(server); got != 1 {
+		t.Errorf("got %d token requests, want 1", got)
+	}
+}
+
+func TestGetTokenCallerCanceled(t *testing.T) {
+	server := fakearm.New(t)
+	server.SetTokenDelay(200 * time.Millisecond)
+	c := testClient(server)
+
+	var token string
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		token, err = c.GetToken(context.Background())
+	}()
+
+	// A caller giving up while the token is requested doesn't fail the request the other caller waits on.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, canceledErr := c.GetToken(ctx); !errors.Is(canceledErr, context.DeadlineExceeded) {
+		t.Errorf("GetToken() = %v, want %v", canceledErr, context.DeadlineExceeded)
+	}
+	<-done
+	if err != nil || token == "" {
+		t.Fatalf("GetToken() = %q, %v, want a token", token, err)
+	}
+	if cached, err := c.GetToken(context.Background()); err != nil || cached != token {
+		t.Errorf("GetToken() = %q, %v, want the cached token %q", cached, err, token)
+	}
+	if got := testTokenRequests(server); got != 1 {
+		t.Errorf("got %d token requests, want 1", got)
+	}
+}
+
+func TestGetTokenRefresh(t *testing.T) {
+	t.Run("half-life", func(t *testing.T) {
+		server := fakearm.New(t)
+		c := testClient(server)
+		start := time.Now()
+		if _, err := c.GetToken(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		refreshAt, ok := c.TokenRefreshAt(client.ManagementScope)
+		if wantMin, wantMax := start.Add(30*time.Minute), time.Now().Add(30*time.Minute); !ok || refreshAt.Before(wantMin) || refreshAt.After(wantMax) {
+			t.Errorf("got refresh at %s, want half of the one hour lifetime, between %s and %s", refreshAt, wantMin, wantMax)
+		}
+	})
+
+	t.Run("refresh_in", func(t *testing.T) {
+		server := fakearm.New(t)
+		server.SetTokenRefreshIn(time.Second)
+		c := testClient(server)
+		first, err := c.GetToken(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(1100 * time.Millisecond)
+
+		// Past refresh_in, the token is still handed out while a new one is requested in the background.
+		if token, err := c.GetToken(context.Background()); err != nil || token != first {
+			t.Fatalf("GetToken() = %q, %v, want the cached token %q", token, err, first)
+		}
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			token, err := c.GetToken(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token != first {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("the token wasn't refreshed")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got := testTokenRequests(server); got != 2 {
+			t.Errorf("got %d token requests, want 2", got)
+		}
+	})
+}
+
+func TestGetTokenExtendedLifetime(t *testing.T) {
+	server := fakearm.New(t)
+	// The token is handed out until two minutes before its expiry, so for a second only.
+	server.SetTokenTTL(2*time.Minute + time.Second)
+	server.SetTokenExtendedTTL(time.Hour)
+	c := testClient(server)
+	first, err := c.GetToken(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+
+	// An AAD outage falls back to the token within its extended lifetime.
+	server.FailTokenRequests(1, http.StatusServiceUnavailable)
+	if token, err := c.GetToken(context.Background()); err != nil || token != first {
+		t.Errorf("GetToken() = %q, %v, want the token %q within its extended lifetime", token, err, first)
+	}
+
+	// AAD rejecting the request doesn't.
+	server.FailTokenRequests(1, http.StatusBadRequest)
+	var authErr *client.AuthenticationError
+	if _, err := c.GetToken(context.Background()); !errors.As(err, &authErr) {
+		t.Errorf("GetToken() = %v, want an authentication error", err)
+	}
+
+	if token, err := c.GetToken(context.Background()); err != nil || token == first {
+		t.Errorf("GetToken() = %q, %v, want a new token", token, err)
+	}
+}
@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// tokenCacheLockTimeout is how long a process waits for another one to fetch a token before fetching on its own.
+	tokenCacheLockTimeout = 30 * time.Second
+	// tokenCacheStaleLock is the age past which a lock file is considered left behind by a crashed process.
+	tokenCacheStaleLock = 2 * time.Minute
+	tokenCacheLockRetry = 100 * time.Millisecond
+)
+
+// FileTokenCache persists tokens on disk so that the provider processes Terraform starts for plan, apply and
+// every remote state lookup share them instead of each requesting its own.
+//
+// Every entry lives in its own file named after a hash of tenant, client ID, authority and scope. Entries are
+// encrypted with AES-GCM under a key derived from the client secret: reading a token requires the secret that
+// allows requesting one anyway. A lock file next to the entry serializes processes refreshing the same token.
+type FileTokenCache struct {
+	dir          string
+	clientSecret string
+	lockTimeout  time.Duration
+}
+
+// NewFileTokenCache returns a cache storing its entries in dir, which is created on first use.
+func NewFileTokenCache(dir, clientSecret string) *FileTokenCache {
+	return &FileTokenCache{dir: dir, clientSecret: clientSecret, lockTimeout: tokenCacheLockTimeout}
+}
+
+// DefaultTokenCacheDir returns the per-user cache directory of the provider.
+func DefaultTokenCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "terraform-provider-azurermext", "tokens"), nil
+}
+
+// WithTokenCache makes the client consult cache before requesting a token from Azure AD.
+func WithTokenCache(cache *FileTokenCache) Option {
+	return func(c *Client) {
+		c.tokenCache = cache
+	}
+}
+
+type tokenCacheKey struct {
+	tenantId  string
+	clientId  string
+	authority string
+	scope     string
+}
+
+func (k tokenCacheKey) hash() string {
+	sum := sha256.Sum256([]byte(k.tenantId + "\n" + k.clientId + "\n" + k.authority + "\n" + k.scope))
+	return hex.EncodeToString(sum[:])
+}
+
+type tokenCacheEntry struct {
+	Token        string    `json:"token"`
+	RefreshAt    time.Time `json:"refresh_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	ExtExpiresAt time.Time `json:"ext_expires_at"`
+}
+
+// getOrRequest returns the cached token for key if it's still usable, otherwise calls request and caches its result.
+// The cache is best effort: any cache failure falls back to calling request directly.
+func (tc *FileTokenCache) getOrRequest(ctx context.Context, key tokenCacheKey, request func() (authToken, error)) (authToken, error) {
+	path := filepath.Join(tc.dir, key.hash())
+	unlock, err := tc.lock(ctx, path)
+	if err != nil {
+		tflog.Debug(ctx, "Token cache unavailable, requesting a new token: "+err.Error())
+		return request()
+	}
+	defer unlock()
+
+	if token, err := tc.load(path, key); err == nil && time.Now().Before(token.refreshAt) {
+		tflog.Debug(ctx, "Using token from the token cache")
+		return token, nil
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		tflog.Debug(ctx, "Ignoring unreadable token cache entry: "+err.Error())
+	}
+
+	token, err := request()
+	if err != nil {
+		return authToken{}, err
+	}
+	if err := tc.store(path, key, token); err != nil {
+		tflog.Debug(ctx, "Could not write token cache entry: "+err.Error())
+	}
+	return token, nil
+}
+
+func (tc *FileTokenCache) load(path string, key tokenCacheKey) (authToken, error) {
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		return authToken{}, err
+	}
+	aead, err := tc.aead(key)
+	if err != nil {
+		return authToken{}, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return authToken{}, errors.New("token cache entry is truncated")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(key.hash()))
+	if err != nil {
+		// Most likely the client secret was rotated, the entry will be overwritten.
+		return authToken{}, fmt.Errorf("decrypting token cache entry: %w", err)
+	}
+	var entry tokenCacheEntry
+	if err := json.Unmarshal(plaintext, &entry); err != nil {
+		return authToken{}, err
+	}
+	return authToken{token: entry.Token, refreshAt: entry.RefreshAt, expiresAt: entry.ExpiresAt, extExpiresAt: entry.ExtExpiresAt}, nil
+}
+
+func (tc *FileTokenCache) store(path string, key tokenCacheKey, token authToken) error {
+	plaintext, err := json.Marshal(tokenCacheEntry{
+		Token:        token.token,
+		RefreshAt:    token.refreshAt,
+		ExpiresAt:    token.expiresAt,
+		ExtExpiresAt: token.extExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+	aead, err := tc.aead(key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(key.hash()))
+
+	// Written to a temporary file then renamed, so that readers never see a partial entry.
+	tmp, err := os.CreateTemp(tc.dir, ".token-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(sealed); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// aead derives the entry key from the client secret, salted with the entry's cache key.
+func (tc *FileTokenCache) aead(key tokenCacheKey) (cipher.AEAD, error) {
+	derived, err := hkdf.Key(sha256.New, []byte(tc.clientSecret), []byte(key.hash()), "terraform-provider-azurermext token cache", 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// lock takes an exclusive lock on an entry with an O_EXCL lock file, which works the same on every platform
+// Terraform runs on. Lock files older than tokenCacheStaleLock are removed.
+func (tc *FileTokenCache) lock(ctx context.Context, path string) (unlock func(), err error) {
+	if err := os.MkdirAll(tc.dir, 0o700); err != nil {
+		return nil, err
+	}
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(tc.lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > tokenCacheStaleLock {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock %s", lockPath)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(tokenCacheLockRetry):
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testTokenCacheKey = tokenCacheKey{
+	tenantId:  "00000000-0000-0000-0000-00000000000a",
+	clientId:  "00000000-0000-0000-0000-00000000000b",
+	authority: "https://login.microsoftonline.com/",
+	scope:     "https://management.azure.com/.default",
+}
+
+// testTokenRequest returns a request func handing out token and counting its calls.
+func testTokenRequest(token authToken, calls *int) func() (authToken, error) {
+	return func() (authToken, error) {
+		*calls++
+		return token, nil
+	}
+}
+
+func testValidToken(name string) authToken {
+	now := time.Now().Truncate(time.Second)
+	return authToken{token: name, refreshAt: now.Add(30 * time.Minute), expiresAt: now.Add(time.Hour), extExpiresAt: now.Add(2 * time.Hour)}
+}
+
+func TestFileTokenCache(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "tokens")
+	path := filepath.Join(dir, testTokenCacheKey.hash())
+	cached := testValidToken("cached")
+	fresh := testValidToken("fresh")
+
+	tests := []struct {
+		name string
+		// prepare sets up the cache directory, already holding an entry for cached stored with the secret "secret".
+		prepare func(t *testing.T)
+		secret  string
+		want    authToken
+	}{
+		{
+			name:    "round trip",
+			prepare: func(*testing.T) {},
+			secret:  "secret",
+			want:    cached,
+		},
+		{
+			name:    "rotated secret",
+			prepare: func(*testing.T) {},
+			secret:  "rotated",
+			want:    fresh,
+		},
+		{
+			name: "truncated entry",
+			prepare: func(t *testing.T) {
+				if err := os.WriteFile(path, []byte{1, 2, 3}, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			secret: "secret",
+			want:   fresh,
+		},
+		{
+			name: "corrupted entry",
+			prepare: func(t *testing.T) {
+				sealed, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				sealed[len(sealed)-1] ^= 0xff
+				if err := os.WriteFile(path, sealed, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			secret: "secret",
+			want:   fresh,
+		},
+		{
+			name: "expired entry",
+			prepare: func(t *testing.T) {
+				expired := cached
+				expired.refreshAt = time.Now().Add(-time.Minute)
+				if err := NewFileTokenCache(dir, "secret").store(path, testTokenCacheKey, expired); err != nil {
+					t.Fatal(err)
+				}
+			},
+			secret: "secret",
+			want:   fresh,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.RemoveAll(dir)
+			calls := 0
+			if _, err := NewFileTokenCache(dir, "secret").getOrRequest(ctx, testTokenCacheKey, testTokenRequest(cached, &calls)); err != nil {
+				t.Fatal(err)
+			}
+			tt.prepare(t)
+
+			cache := NewFileTokenCache(dir, tt.secret)
+			calls = 0
+			got, err := cache.getOrRequest(ctx, testTokenCacheKey, testTokenRequest(fresh, &calls))
+			if err != nil {
+				t.Fatalf("getOrRequest() failed: %v", err)
+			}
+			if !testEqualTokens(got, tt.want) {
+				t.Errorf("getOrRequest() = %+v, want %+v", got, tt.want)
+			}
+			wantCalls := 1
+			if tt.want == cached {
+				wantCalls = 0
+			}
+			if calls != wantCalls {
+				t.Errorf("requested %d tokens, want %d", calls, wantCalls)
+			}
+			// A requested token replaces the unusable entry.
+			if stored, err := cache.load(path, testTokenCacheKey); err != nil || !testEqualTokens(stored, tt.want) {
+				t.Errorf("load() = %+v, %v, want %+v", stored, err, tt.want)
+			}
+			if _, err := os.Stat(path + ".lock"); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("the lock file is left behind: %v", err)
+			}
+		})
+	}
+}
+
+func TestFileTokenCacheLock(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, testTokenCacheKey.hash())
+	cache := NewFileTokenCache(dir, "secret")
+	cache.lockTimeout = 200 * time.Millisecond
+
+	t.Run("timeout", func(t *testing.T) {
+		// The lock of another process which is still refreshing the token.
+		if err := os.WriteFile(path+".lock", nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(path + ".lock")
+		start := time.Now()
+		if _, err := cache.lock(ctx, path); err == nil {
+			t.Fatal("lock() got no error")
+		}
+		if elapsed := time.Since(start); elapsed < cache.lockTimeout {
+			t.Errorf("lock() gave up after %s, want it to wait %s", elapsed, cache.lockTimeout)
+		}
+
+		// The cache is best effort: the token is requested without it.
+		calls := 0
+		got, err := cache.getOrRequest(ctx, testTokenCacheKey, testTokenRequest(testValidToken("fresh"), &calls))
+		if err != nil || got.token != "fresh" || calls != 1 {
+			t.Errorf("getOrRequest() = %+v, %v after %d requests, want the requested token", got, err, calls)
+		}
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("the entry was written without holding the lock: %v", err)
+		}
+	})
+
+	t.Run("stale lock", func(t *testing.T) {
+		// The lock of a process which crashed.
+		if err := os.WriteFile(path+".lock", nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		stale := time.Now().Add(-tokenCacheStaleLock - time.Minute)
+		if err := os.Chtimes(path+".lock", stale, stale); err != nil {
+			t.Fatal(err)
+		}
+		unlock, err := cache.lock(ctx, path)
+		if err != nil {
+			t.Fatalf("lock() failed: %v", err)
+		}
+		info, err := os.Stat(path + ".lock")
+		if err != nil || info.ModTime().Before(time.Now().Add(-time.Minute)) {
+			t.Errorf("the stale lock wasn't replaced: %v", err)
+		}
+		unlock()
+		if _, err := os.Stat(path + ".lock"); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("unlock() left the lock file: %v", err)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		if err := os.WriteFile(path+".lock", nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(path + ".lock")
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		if _, err := cache.lock(canceled, path); !errors.Is(err, context.Canceled) {
+			t.Errorf("lock() = %v, want %v", err, context.Canceled)
+		}
+	})
+}
+
+// testEqualTokens compares tokens ignoring the monotonic clock readings lost by the JSON encoding.
+func testEqualTokens(a, b authToken) bool {
+	return a.token == b.token && a.refreshAt.Equal(b.refreshAt) && a.expiresAt.Equal(b.expiresAt) && a.extExpiresAt.Equal(b.extExpiresAt)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// TransportConfig describes how the provider reaches Azure in restricted networks.
+// The zero value behaves like http.DefaultClient.
+type TransportConfig struct {
+	// ProxyURL is used for every request when set, otherwise HTTPS_PROXY/HTTP_PROXY are honoured.
+	// NO_PROXY applies in both cases.
+	ProxyURL string
+	// CACertificatesFile is a PEM bundle trusted on top of the system roots, e.g. the CA of a TLS-inspecting proxy.
+	CACertificatesFile string
+	// RequestTimeout bounds every single HTTP request, including reading the response body. Zero means no timeout.
+	RequestTimeout    time.Duration
+	DisableKeepAlives bool
+}
+
+// NewHTTPClient builds an HTTP client from the transport configuration, to be passed to WithHTTPClient.
+func NewHTTPClient(config TransportConfig) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableKeepAlives = config.DisableKeepAlives
+
+	proxyConfig := httpproxy.FromEnvironment()
+	if config.ProxyURL != "" {
+		proxyUrl, err := url.Parse(config.ProxyURL)
+		if err != nil || proxyUrl.Scheme == "" || proxyUrl.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q: it must be an absolute URL such as http://proxy.example.com:3128", config.ProxyURL)
+		}
+		proxyConfig.HTTPProxy = config.ProxyURL
+		proxyConfig.HTTPSProxy = config.ProxyURL
+	}
+	proxyFunc := proxyConfig.ProxyFunc()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+
+	if config.CACertificatesFile != "" {
+		pem, err := os.ReadFile(config.CACertificatesFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA certificates file: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA certificates file %s doesn't contain any PEM encoded certificate", config.CACertificatesFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+
+	return &http.Client{Transport: transport, Timeout: config.RequestTimeout}, nil
+}
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCACertificatesFile writes the certificate of server to a PEM file and returns its path.
+func testCACertificatesFile(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(path, certificate, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewHTTPClientCACertificates(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	t.Run("system roots", func(t *testing.T) {
+		c, err := NewHTTPClient(TransportConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var unknownAuthority x509.UnknownAuthorityError
+		if _, err := c.Get(server.URL); !errors.As(err, &unknownAuthority) {
+			t.Errorf("Get() = %v, want the certificate of the server rejected", err)
+		}
+	})
+
+	t.Run("CA certificates file", func(t *testing.T) {
+		path := testCACertificatesFile(t, server)
+		c, err := NewHTTPClient(TransportConfig{CACertificatesFile: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get() failed: %v", err)
+		}
+		resp.Body.Close()
+
+		// The file is added to the system roots rather than replacing them.
+		want, err := x509.SystemCertPool()
+		if err != nil {
+			t.Skipf("no system roots: %v", err)
+		}
+		certificate, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want.AppendCertsFromPEM(certificate)
+		if !c.Transport.(*http.Transport).TLSClientConfig.RootCAs.Equal(want) {
+			t.Error("the root certificates aren't the system roots and the file's")
+		}
+	})
+
+	t.Run("unreadable file", func(t *testing.T) {
+		_, err := NewHTTPClient(TransportConfig{CACertificatesFile: filepath.Join(t.TempDir(), "missing.pem")})
+		if !errors.Is(err, os.ErrNotExist) || !strings.Contains(err.Error(), "reading CA certificates file") {
+			t.Errorf("NewHTTPClient() error = %v, want the file not found", err)
+		}
+	})
+
+	t.Run("invalid PEM", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ca.pem")
+		if err := os.WriteFile(path, []byte("-----BEGIN CERTIFICATE-----\nnot base64\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := NewHTTPClient(TransportConfig{CACertificatesFile: path})
+		if err == nil || !strings.Contains(err.Error(), "doesn't contain any PEM encoded certificate") {
+			t.Errorf("NewHTTPClient() error = %v, want an invalid PEM error", err)
+		}
+	})
+}
+
+func TestNewHTTPClientProxy(t *testing.T) {
+	// A forward proxy receives the absolute URL of plain HTTP requests. Loopback targets are never proxied, so the
+	// target host doesn't need to resolve.
+	proxied := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied <- r.URL.String()
+		io.WriteString(w, "ok")
+	}))
+	defer proxy.Close()
+	environmentProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("the proxy of the environment got %s", r.URL)
+	}))
+	defer environmentProxy.Close()
+	t.Setenv("HTTP_PROXY", environmentProxy.URL)
+	t.Setenv("HTTPS_PROXY", environmentProxy.URL)
+	t.Setenv("NO_PROXY", "login.example.test")
+
+	c, err := NewHTTPClient(TransportConfig{ProxyURL: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Get("http://management.example.test/subscriptions")
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	resp.Body.Close()
+	select {
+	case got := <-proxied:
+		if got != "http://management.example.test/subscriptions" {
+			t.Errorf("the proxy got %s", got)
+		}
+	default:
+		t.Error("the request didn't go through proxy_url")
+	}
+
+	// NO_PROXY still applies.
+	proxyFunc := c.Transport.(*http.Transport).Proxy
+	for target, want := range map[string]string{
+		"https://management.example.test/": proxy.URL,
+		"https://login.example.test/":      "",
+	} {
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := proxyFunc(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (got == nil && want != "") || (got != nil && got.String() != want) {
+			t.Errorf("proxy of %s = %v, want %q", target, got, want)
+		}
+	}
+
+	// Without proxy_url, the environment's proxy is used.
+	c, err = NewHTTPClient(TransportConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://management.example.test/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.Transport.(*http.Transport).Proxy(req); err != nil || got == nil || got.String() != environmentProxy.URL {
+		t.Errorf("proxy = %v, %v, want %s", got, err, environmentProxy.URL)
+	}
+
+	for _, invalid := range []string{"proxy.example.test:3128", "http://", "://proxy"} {
+		if _, err := NewHTTPClient(TransportConfig{ProxyURL: invalid}); err == nil || !strings.Contains(err.Error(), "invalid proxy URL") {
+			t.Errorf("NewHTTPClient(%q) error = %v, want an invalid proxy URL error", invalid, err)
+		}
+	}
+}
+
+func TestNewHTTPClientRequestTimeout(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-body" {
+			io.WriteString(w, "partial")
+			w.(http.Flusher).Flush()
+		}
+		select {
+		case <-time.After(5 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	c, err := NewHTTPClient(TransportConfig{CACertificatesFile: testCACertificatesFile(t, server), RequestTimeout: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var netErr net.Error
+	if _, err := c.Get(server.URL + "/slow-headers"); !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("Get() = %v, want a timeout", err)
+	}
+
+	// The timeout also bounds reading the body.
+	resp, err := c.Get(server.URL + "/slow-body")
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.ReadAll(resp.Body); !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("ReadAll() = %v, want a timeout", err)
+	}
+}
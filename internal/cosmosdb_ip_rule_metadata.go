@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"terraform-provider-azurermext/internal/client"
+	"terraform-provider-azurermext/internal/rulemetadata"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// defaultIpRuleMetadataTag is the account tag the description and owner of IP rules are stored under by default.
+const defaultIpRuleMetadataTag = "azurermext-ip-rules"
+
+// ipRuleMetadata returns the metadata of the rules which have any, by IP.
+func ipRuleMetadata(rules []ipRuleModel) map[string]rulemetadata.Metadata {
+	metadata := map[string]rulemetadata.Metadata{}
+	for _, rule := range rules {
+		m := rulemetadata.Metadata{Description: rule.Description.ValueString(), Owner: rule.Owner.ValueString()}
+		if !m.IsZero() {
+			metadata[rule.Ip.ValueString()] = m
+		}
+	}
+	return metadata
+}
+
+// ipRuleMetadataTagsChange computes the tag updates storing the metadata of rules under key. Like the IP rules, the
+// metadata is merged additively: the entries of the previously managed IPs are replaced and the entries of rules
+// managed by other resources are kept. merge holds the tags to add or update and remove the tags to delete.
+func ipRuleMetadataTagsChange(key string, current map[string]string, previous []string, rules map[string]rulemetadata.Metadata) (merge, remove map[string]string, err error) {
+	metadata, err := rulemetadata.Decode(key, current)
+	if err != nil {
+		// The tag was edited by hand, nothing can be kept from it.
+		metadata = map[string]rulemetadata.Metadata{}
+	}
+	for _, ip := range previous {
+		delete(metadata, ip)
+	}
+	for ip, m := range rules {
+		metadata[ip] = m
+	}
+	tags, err := rulemetadata.Encode(key, metadata)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merge, remove = map[string]string{}, map[string]string{}
+	for name, value := range tags {
+		if currentValue, ok := current[name]; !ok || currentValue != value {
+			merge[name] = value
+		}
+	}
+	for _, name := range rulemetadata.TagNames(key, current) {
+		if _, ok := tags[name]; !ok {
+			remove[name] = current[name]
+		}
+	}
+	return merge, remove, nil
+}
+
+// previousIpRuleMetadata returns the tag the metadata of state was recorded in and the IPs it was recorded for. A nil
+// state, i.e. a resource being created, recorded nothing.
+func previousIpRuleMetadata(ctx context.Context, state *CosmosDBMongoDBIpFilterResourceModel, diags *diag.Diagnostics) (key string, ips []string) {
+	if state == nil {
+		return "", nil
+	}
+	key = state.MetadataTag.ValueString()
+	if state.MetadataTag.IsNull() {
+		key = defaultIpRuleMetadataTag
+	}
+	return key, ipRuleIps(ipRulesFromList(ctx, state.IpRules, diags))
+}
+
+// writeIpRuleMetadata records the description and owner of the planned rules in the tags of the account, removing
+// the metadata of the previously managed rules, also from the previous tag when metadata_tag changed. The adapter
+// must have read the account.
+func (r *CosmosDBIpFilterResource) writeIpRuleMetadata(ctx context.Context, adapter *cosmosDBIpRuleAdapter, state, plan *CosmosDBMongoDBIpFilterResourceModel, diags *diag.Diagnostics) {
+	cosmosID := plan.CosmosDBAccountId.ValueString()
+	key := plan.MetadataTag.ValueString()
+	previousKey, previous := previousIpRuleMetadata(ctx, state, diags)
+	rules := ipRuleMetadata(ipRulesFromList(ctx, plan.IpRules, diags))
+	if diags.HasError() {
+		return
+	}
+
+	// Resources sharing the account rewrite the same tags: their updates are serialized, and the tags are read again
+	// once locked so that the metadata another resource just recorded is kept.
+	unlock := r.client.LockResource(adapter.resourceID())
+	defer unlock()
+	account, err := r.client.ReadCosmosDB(ctx, adapter.parsedId)
+	if err != nil {
+		addClientError(diags, "Could not record CosmosDB IP rule metadata", "Failed to read the tags of CosmosDB account "+cosmosID, err)
+		return
+	}
+	keys := []string{key}
+	if previousKey != "" && !strings.EqualFold(previousKey, key) {
+		keys = append(keys, previousKey)
+	}
+	for _, k := range keys {
+		var recorded map[string]rulemetadata.Metadata
+		if k == key {
+			recorded = rules
+		}
+		merge, remove, err := ipRuleMetadataTagsChange(k, account.Tags, previous, recorded)
+		if err != nil {
+			addIpRuleMetadataError(cosmosID, err, diags)
+			return
+		}
+		err = r.client.UpdateTags(ctx, adapter.parsedId, client.TagsOperationMerge, merge)
+		if err == nil {
+			err = r.client.UpdateTags(ctx, adapter.parsedId, client.TagsOperationDelete, remove)
+		}
+		if err != nil {
+			addClientError(
+				diags,
+				"Could not record CosmosDB IP rule metadata",
+				"Failed to update the "+k+" tags of CosmosDB account "+cosmosID,
+				err,
+			)
+			return
+		}
+	}
+}
+
+// addIpRuleMetadataError reports metadata which can't be encoded in the tags of the account.
+func addIpRuleMetadataError(cosmosID string, err error, diags *diag.Diagnostics) {
+	detail := "The description and owner of the IP rules of CosmosDB account " + cosmosID + " can't be recorded: " + err.Error() + "."
+	var tooLarge *rulemetadata.TooLargeError
+	if errors.As(err, &tooLarge) {
+		detail += " Shorten the descriptions and owners, or record them in another metadata_tag."
+	}
+	diags.AddAttributeError(path.Root("ip_rules"), "Invalid IP rule metadata", detail)
+}
+
+// refreshIpRuleMetadata sets the description and owner of rules to the recorded ones, so that a tag changed or
+// removed outside of Terraform shows as drift. Empty values are kept as configured since they're never recorded.
+func refreshIpRuleMetadata(rules []ipRuleModel, metadata map[string]rulemetadata.Metadata) []ipRuleModel {
+	refreshed := make([]ipRuleModel, 0, len(rules))
+	for _, rule := range rules {
+		recorded := metadata[rule.Ip.ValueString()]
+		rule.Description = refreshMetadataValue(rule.Description, recorded.Description)
+		rule.Owner = refreshMetadataValue(rule.Owner, recorded.Owner)
+		refreshed = append(refreshed, rule)
+	}
+	return refreshed
+}
+
+func refreshMetadataValue(value types.String, recorded string) types.String {
+	switch {
+	case recorded != "":
+		return types.StringValue(recorded)
+	case value.ValueString() != "":
+		return types.StringNull()
+	default:
+		return value
+	}
+}
@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"context"
+	"terraform-provider-azurermext/internal/cidr"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ipRuleExpiryWarning is how long before their expiry IP rules are reported by Read.
+const ipRuleExpiryWarning = 7 * 24 * time.Hour
+
+// ipRuleModel is an entry of the ip_rules of azurermext_cosmosdb_ip_range_filter.
+type ipRuleModel struct {
+	Ip          types.String `tfsdk:"ip"`
+	ExpiresAt   types.String `tfsdk:"expires_at"`
+	Description types.String `tfsdk:"description"`
+	Owner       types.String `tfsdk:"owner"`
+}
+
+var ipRuleObjectType = types.ObjectType{AttrTypes: map[string]attr.Type{
+	"ip":          types.StringType,
+	"expires_at":  types.StringType,
+	"description": types.StringType,
+	"owner":       types.StringType,
+}}
+
+// newIpRule returns a rule which never expires and has no metadata.
+func newIpRule(ip string) ipRuleModel {
+	return ipRuleModel{Ip: types.StringValue(ip), ExpiresAt: types.StringNull(), Description: types.StringNull(), Owner: types.StringNull()}
+}
+
+// expiresAt returns the expiry of the rule. ok is false for rules which never expire.
+// expires_at is validated at plan time, an invalid value is considered as never expiring.
+func (m ipRuleModel) expiresAt() (_ time.Time, ok bool) {
+	if m.ExpiresAt.IsNull() || m.ExpiresAt.IsUnknown() {
+		return time.Time{}, false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, m.ExpiresAt.ValueString())
+	return expiresAt, err == nil
+}
+
+func (m ipRuleModel) expired(now time.Time) bool {
+	expiresAt, ok := m.expiresAt()
+	return ok && !now.Before(expiresAt)
+}
+
+func ipRulesFromList(ctx context.Context, list types.List, diags *diag.Diagnostics) []ipRuleModel {
+	rules := []ipRuleModel{}
+	if list.IsNull() || list.IsUnknown() {
+		return rules
+	}
+	diags.Append(list.ElementsAs(ctx, &rules, false)...)
+	return rules
+}
+
+func ipRulesToList(ctx context.Context, rules []ipRuleModel, diags *diag.Diagnostics) types.List {
+	list, d := types.ListValueFrom(ctx, ipRuleObjectType, rules)
+	diags.Append(d...)
+	return list
+}
+
+// ipRulesKnown reports whether every attribute of every rule is known.
+func ipRulesKnown(rules []ipRuleModel) bool {
+	for _, rule := range rules {
+		if rule.Ip.IsUnknown() || rule.ExpiresAt.IsUnknown() || rule.Description.IsUnknown() || rule.Owner.IsUnknown() {
+			return false
+		}
+	}
+	return true
+}
+
+// ipRuleIps returns the IPs of every rule, expired or not.
+func ipRuleIps(rules []ipRuleModel) []string {
+	ips := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		ips = append(ips, rule.Ip.ValueString())
+	}
+	return ips
+}
+
+// activeIpRuleIps returns the IPs of the rules which haven't expired at now, that is the IPs to allow.
+func activeIpRuleIps(rules []ipRuleModel, now time.Time) []string {
+	ips := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		if !rule.expired(now) {
+			ips = append(ips, rule.Ip.ValueString())
+		}
+	}
+	return ips
+}
+
+// expiredIpRuleIps returns the IPs of the rules which have expired at now.
+func expiredIpRuleIps(rules []ipRuleModel, now time.Time) []string {
+	ips := []string{}
+	for _, rule := range rules {
+		if rule.expired(now) {
+			ips = append(ips, rule.Ip.ValueString())
+		}
+	}
+	return ips
+}
+
+// refreshIpRules returns the managed rules as they stand on the account. A rule is in sync when it's live and
+// active, or when it's expired and gone. Any other rule is left out so that the next plan shows a diff: an active
+// rule someone removed is added back, an expired rule still live is removed.
+//
+// In authoritative mode the live rules which aren't managed follow, so that they show as drift as well.
+func refreshIpRules(managed []ipRuleModel, current []string, authoritative bool, now time.Time) []ipRuleModel {
+	live := make(map[string]struct{}, len(current))
+	for _, ip := range current {
+		live[ip] = struct{}{}
+	}
+	refreshed := []ipRuleModel{}
+	managedIps := make(map[string]struct{}, len(managed))
+	for _, rule := range managed {
+		managedIps[rule.Ip.ValueString()] = struct{}{}
+		_, isLive := live[rule.Ip.ValueString()]
+		if expired := rule.expired(now); isLive != expired {
+			refreshed = append(refreshed, rule)
+		}
+	}
+	if authoritative {
+		for _, ip := range current {
+			if _, ok := managedIps[ip]; !ok {
+				refreshed = append(refreshed, newIpRule(ip))
+			}
+		}
+	}
+	return refreshed
+}
+
+// ipRulesView rewrites the live rules of an account in terms of ip_rules, for refreshIpRules: a rule written by the
+// resource, possibly aggregated or from a service tag, stands for the active managed rules it covers. The other live
+// rules are kept as is.
+func ipRulesView(managed []ipRuleModel, written, current []string, now time.Time) []string {
+	writtenKeys := make(map[string]struct{}, len(written))
+	for _, ip := range written {
+		writtenKeys[ip] = struct{}{}
+	}
+	active := activeIpRuleIps(managed, now)
+	seen := map[string]struct{}{}
+	view := []string{}
+	for _, ip := range current {
+		covered := []string{ip}
+		if _, ok := writtenKeys[ip]; ok {
+			covered = coveredIpRules(active, []string{ip})
+		}
+		for _, ip := range covered {
+			if _, ok := seen[ip]; !ok {
+				seen[ip] = struct{}{}
+				view = append(view, ip)
+			}
+		}
+	}
+	return view
+}
+
+// coveredIpRules returns the values contained in any of the ranges. Values which aren't IPs or CIDR ranges are only
+// covered by an identical range.
+func coveredIpRules(values, ranges []string) []string {
+	covered := []string{}
+	for _, value := range values {
+		for _, r := range ranges {
+			if contains, err := cidr.Contains(r, value); contains || (err != nil && r == value) {
+				covered = append(covered, value)
+				break
+			}
+		}
+	}
+	return covered
+}
+
+// expiringIpRules returns the rules which are active at now but expire within ipRuleExpiryWarning.
+func expiringIpRules(rules []ipRuleModel, now time.Time) []ipRuleModel {
+	expiring := []ipRuleModel{}
+	for _, rule := range rules {
+		if expiresAt, ok := rule.expiresAt(); ok && now.Before(expiresAt) && expiresAt.Sub(now) <= ipRuleExpiryWarning {
+			expiring = append(expiring, rule)
+		}
+	}
+	return expiring
+}
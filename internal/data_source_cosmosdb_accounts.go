@@ -0,0 +1,255 @@
+package internal
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"terraform-provider-azurermext/internal/client"
+	"terraform-provider-azurermext/internal/resourceid"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ datasource.DataSourceWithConfigure = (*CosmosDBAccountsDataSource)(nil)
+)
+
+type CosmosDBAccountsDataSource struct {
+	client *client.Client
+}
+
+type CosmosDBAccountsDataSourceModel struct {
+	Scopes   types.List `tfsdk:"scopes"`
+	Tags     types.Map  `tfsdk:"tags"`
+	Ids      types.List `tfsdk:"ids"`
+	Accounts types.List `tfsdk:"accounts"`
+}
+
+// cosmosDBAccountModel is an entry of the accounts of the azurermext_cosmosdb_accounts data source.
+type cosmosDBAccountModel struct {
+	ID                      types.String `tfsdk:"id"`
+	Name                    types.String `tfsdk:"name"`
+	ResourceGroupName       types.String `tfsdk:"resource_group_name"`
+	SubscriptionId          types.String `tfsdk:"subscription_id"`
+	Location                types.String `tfsdk:"location"`
+	PublicNetworkAccess     types.String `tfsdk:"public_network_access"`
+	IpRuleCount             types.Int64  `tfsdk:"ip_rule_count"`
+	VirtualNetworkRuleCount types.Int64  `tfsdk:"virtual_network_rule_count"`
+	Tags                    types.Map    `tfsdk:"tags"`
+}
+
+var cosmosDBAccountObjectType = types.ObjectType{AttrTypes: map[string]attr.Type{
+	"id":                         types.StringType,
+	"name":                       types.StringType,
+	"resource_group_name":        types.StringType,
+	"subscription_id":            types.StringType,
+	"location":                   types.StringType,
+	"public_network_access":      types.StringType,
+	"ip_rule_count":              types.Int64Type,
+	"virtual_network_rule_count": types.Int64Type,
+	"tags":                       types.MapType{ElemType: types.StringType},
+}}
+
+func NewCosmosDBAccountsDataSource() datasource.DataSource {
+	return &CosmosDBAccountsDataSource{}
+}
+
+func (d *CosmosDBAccountsDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_cosmosdb_accounts"
+}
+
+func (d *CosmosDBAccountsDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.client = req.ProviderData.(*client.Client)
+}
+
+func (d *CosmosDBAccountsDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: cosmosDbAccountsDescription,
+		Attributes: map[string]schema.Attribute{
+			"scopes": schema.ListAttribute{
+				ElementType: types.StringType,
+				Validators:  []validator.List{scopeValidator{}},
+				Optional:    true,
+				Description: "IDs of the subscriptions or resource groups to list the CosmosDB accounts of, e.g. `/subscriptions/{id}/resourceGroups/{name}`. Defaults to the provider's `subscription_id`.",
+			},
+			"tags": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Only returns the accounts having all these tags with these values. Tag names are case-insensitive, values are not.",
+			},
+			"ids": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "Resource IDs of the accounts, sorted, e.g. to set the `cosmosdb_account_ids` of an `azurermext_cosmosdb_ip_range_filter_bulk`.",
+			},
+			"accounts": schema.ListNestedAttribute{
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							Computed:    true,
+							Description: "Resource ID of the account.",
+						},
+						"name": schema.StringAttribute{
+							Computed:    true,
+							Description: "Name of the account.",
+						},
+						"resource_group_name": schema.StringAttribute{
+							Computed:    true,
+							Description: "Resource group of the account.",
+						},
+						"subscription_id": schema.StringAttribute{
+							Computed:    true,
+							Description: "Subscription of the account.",
+						},
+						"location": schema.StringAttribute{
+							Computed:    true,
+							Description: "Location of the account, e.g. `westeurope`.",
+						},
+						"public_network_access": schema.StringAttribute{
+							Computed:    true,
+							Description: "Whether the account can be reached from public networks: `Enabled`, `Disabled` or `SecuredByPerimeter`. IP rules only apply to `Enabled` accounts.",
+						},
+						"ip_rule_count": schema.Int64Attribute{
+							Computed:    true,
+							Description: "Number of IP rules of the account, at most 1000.",
+						},
+						"virtual_network_rule_count": schema.Int64Attribute{
+							Computed:    true,
+							Description: "Number of virtual network rules of the account.",
+						},
+						"tags": schema.MapAttribute{
+							ElementType: types.StringType,
+							Computed:    true,
+							Description: "Tags of the account.",
+						},
+					},
+				},
+				Computed:    true,
+				Description: "The CosmosDB accounts of `scopes` matching `tags`, sorted by ID.",
+			},
+		},
+	}
+}
+
+func (d *CosmosDBAccountsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config CosmosDBAccountsDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	scopes := listToStrings(config.Scopes)
+	if config.Scopes.IsNull() {
+		if d.client.SubscriptionID() == "" {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("scopes"),
+				"Missing scopes",
+				"No subscription_id is configured in the provider, scopes must be set.",
+			)
+			return
+		}
+		scopes = []string{"/subscriptions/" + d.client.SubscriptionID()}
+	}
+	tags := map[string]string{}
+	resp.Diagnostics.Append(config.Tags.ElementsAs(ctx, &tags, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	seen := map[string]struct{}{}
+	var accounts []client.CosmosDBResponse
+	for i, scope := range scopes {
+		parsedScope, err := resourceid.Parse(scope)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("scopes").AtListIndex(i), "Invalid scope", err.Error())
+			return
+		}
+		listed, err := d.client.ListCosmosDBAccounts(ctx, parsedScope)
+		if err != nil {
+			addClientError(&resp.Diagnostics, "Could not list CosmosDB accounts", "Failed to list the CosmosDB accounts of "+scope, err)
+			return
+		}
+		for _, account := range listed {
+			// Scopes may overlap, e.g. a subscription and one of its resource groups.
+			if _, ok := seen[strings.ToLower(account.ID)]; !ok && hasTags(account.Tags, tags) {
+				seen[strings.ToLower(account.ID)] = struct{}{}
+				accounts = append(accounts, account)
+			}
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool { return strings.ToLower(accounts[i].ID) < strings.ToLower(accounts[j].ID) })
+
+	ids := []string{}
+	models := []cosmosDBAccountModel{}
+	for _, account := range accounts {
+		ids = append(ids, account.ID)
+		models = append(models, cosmosDBAccountToModel(ctx, account, &resp.Diagnostics))
+	}
+	config.Ids = stringsToList(ctx, ids, &resp.Diagnostics)
+	list, diags := types.ListValueFrom(ctx, cosmosDBAccountObjectType, models)
+	resp.Diagnostics.Append(diags...)
+	config.Accounts = list
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+}
+
+// hasTags reports whether tags holds every tag of filter with the same value. Tag names are compared ignoring case
+// like ARM does.
+func hasTags(tags, filter map[string]string) bool {
+	for name, value := range filter {
+		found := false
+		for tagName, tagValue := range tags {
+			if strings.EqualFold(tagName, name) && tagValue == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func cosmosDBAccountToModel(ctx context.Context, account client.CosmosDBResponse, diags *diag.Diagnostics) cosmosDBAccountModel {
+	model := cosmosDBAccountModel{
+		ID:                      types.StringValue(account.ID),
+		Name:                    types.StringValue(account.Name),
+		ResourceGroupName:       types.StringNull(),
+		SubscriptionId:          types.StringNull(),
+		Location:                types.StringValue(account.Location),
+		PublicNetworkAccess:     types.StringNull(),
+		IpRuleCount:             types.Int64Value(0),
+		VirtualNetworkRuleCount: types.Int64Value(0),
+	}
+	if id, err := resourceid.Parse(account.ID); err == nil {
+		model.ResourceGroupName = types.StringValue(id.ResourceGroup)
+		model.SubscriptionId = types.StringValue(id.SubscriptionID)
+		if account.Name == "" {
+			model.Name = types.StringValue(id.Name())
+		}
+	}
+	if account.Properties != nil {
+		model.PublicNetworkAccess = stringOrNull(string(account.Properties.PublicNetworkAccess))
+		model.IpRuleCount = types.Int64Value(int64(len(account.Properties.IpRules)))
+		model.VirtualNetworkRuleCount = types.Int64Value(int64(len(account.Properties.VirtualNetworkRules)))
+	}
+	tags := account.Tags
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	var d diag.Diagnostics
+	model.Tags, d = types.MapValueFrom(ctx, types.StringType, tags)
+	diags.Append(d...)
+	return model
+}
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"terraform-provider-azurermext/internal/testing/fakearm"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccCosmosDBAccountsDataSource_basic(t *testing.T) {
+	const otherSubscriptionId = "00000000-0000-0000-0000-000000000002"
+	server := fakearm.New(t)
+	server.SetPageSize(1)
+	tagged := func(tags map[string]string) func(*fakearm.CosmosDBAccount) {
+		return func(account *fakearm.CosmosDBAccount) { account.Tags = tags }
+	}
+	prodA := testCosmosDBAccountId("prod-a")
+	prodB := "/subscriptions/" + testSubscriptionId + "/resourceGroups/other/providers/Microsoft.DocumentDB/databaseAccounts/prod-b"
+	staging := testCosmosDBAccountId("staging")
+	foreign := "/subscriptions/" + otherSubscriptionId + "/resourceGroups/rg/providers/Microsoft.DocumentDB/databaseAccounts/foreign"
+	for _, id := range []string{prodA, prodB, staging, foreign} {
+		server.AddCosmosDBAccount(id, []string{"1.1.1.1", "2.2.2.2"})
+	}
+	server.UpdateCosmosDBAccount(prodA, tagged(map[string]string{"Env": "prod", "team": "data"}))
+	server.UpdateCosmosDBAccount(prodB, tagged(map[string]string{"ENV": "prod"}))
+	server.UpdateCosmosDBAccount(staging, tagged(map[string]string{"env": "Prod"}))
+	server.UpdateCosmosDBAccount(foreign, tagged(map[string]string{"env": "prod"}))
+	config := func(arguments string) string {
+		return fmt.Sprintf(`
+provider "azurermext" {
+  tenant_id       = %q
+  client_id       = %q
+  client_secret   = %q
+  subscription_id = %q
+}
+
+data "azurermext_cosmosdb_accounts" "test" {
+%s
+}
+`, fakearm.TenantID, fakearm.ClientID, fakearm.ClientSecret, testSubscriptionId, arguments)
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				// Every page of the provider's subscription is listed, sorted by ID.
+				Config: config(""),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "ids.#", "3"),
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "ids.0", prodB),
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "ids.1", prodA),
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "ids.2", staging),
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "accounts.1.name", "prod-a"),
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "accounts.1.resource_group_name", "rg"),
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "accounts.1.subscription_id", testSubscriptionId),
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "accounts.1.location", "westeurope"),
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "accounts.1.public_network_access", "Enabled"),
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "accounts.1.ip_rule_count", "2"),
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "accounts.1.tags.team", "data"),
+					testCheckCosmosDBAccountListPages(server, "/subscriptions/"+testSubscriptionId, 3),
+				),
+			},
+			{
+				// Tag names match ignoring case, values don't.
+				Config: config(`  tags = { env = "prod" }`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "ids.#", "2"),
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "ids.0", prodB),
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "ids.1", prodA),
+				),
+			},
+			{
+				// Overlapping scopes list an account once.
+				Config: config(fmt.Sprintf(`  scopes = [%q, %q, %q]
+  tags   = { ENV = "prod" }`,
+					"/subscriptions/"+testSubscriptionId+"/resourceGroups/rg", "/subscriptions/"+testSubscriptionId, "/subscriptions/"+otherSubscriptionId)),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "ids.#", "3"),
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "ids.0", prodB),
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "ids.1", prodA),
+					resource.TestCheckResourceAttr("data.azurermext_cosmosdb_accounts.test", "ids.2", foreign),
+				),
+			},
+		},
+	})
+}
+
+// testCheckCosmosDBAccountListPages checks that the CosmosDB accounts of scope were listed in at least the given
+// number of pages, following nextLink.
+func testCheckCosmosDBAccountListPages(server *fakearm.Server, scope string, atLeast int) func(*terraform.State) error {
+	return func(*terraform.State) error {
+		pages := 0
+		for _, request := range server.Requests() {
+			if request.Method == http.MethodGet && strings.EqualFold(request.Path, scope+"/providers/Microsoft.DocumentDB/databaseAccounts") {
+				pages++
+			}
+		}
+		if pages < atLeast {
+			return fmt.Errorf("listed the CosmosDB accounts of %s in %d pages, want at least %d", scope, pages, atLeast)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,195 @@
+package internal
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"terraform-provider-azurermext/internal/cidr"
+	"terraform-provider-azurermext/internal/client"
+	"terraform-provider-azurermext/internal/resourceid"
+	"terraform-provider-azurermext/internal/rulemetadata"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ datasource.DataSourceWithConfigure = (*CosmosDBIpRulesDataSource)(nil)
+)
+
+type CosmosDBIpRulesDataSource struct {
+	client *client.Client
+}
+
+type CosmosDBIpRulesDataSourceModel struct {
+	CosmosDBAccountId types.String `tfsdk:"cosmosdb_account_id"`
+	MetadataTag       types.String `tfsdk:"metadata_tag"`
+	IpRules           types.List   `tfsdk:"ip_rules"`
+}
+
+// liveIpRuleModel is an entry of the ip_rules of the azurermext_cosmosdb_ip_rules data source.
+type liveIpRuleModel struct {
+	Ip          types.String `tfsdk:"ip"`
+	Description types.String `tfsdk:"description"`
+	Owner       types.String `tfsdk:"owner"`
+	RecordedIps types.List   `tfsdk:"recorded_ips"`
+}
+
+var liveIpRuleObjectType = types.ObjectType{AttrTypes: map[string]attr.Type{
+	"ip":           types.StringType,
+	"description":  types.StringType,
+	"owner":        types.StringType,
+	"recorded_ips": types.ListType{ElemType: types.StringType},
+}}
+
+func NewCosmosDBIpRulesDataSource() datasource.DataSource {
+	return &CosmosDBIpRulesDataSource{}
+}
+
+func (d *CosmosDBIpRulesDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_cosmosdb_ip_rules"
+}
+
+func (d *CosmosDBIpRulesDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.client = req.ProviderData.(*client.Client)
+}
+
+func (d *CosmosDBIpRulesDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: cosmosDbIpRulesDescription,
+		Attributes: map[string]schema.Attribute{
+			"cosmosdb_account_id": schema.StringAttribute{
+				Validators:  []validator.String{resourceIdValidator{cosmosDBAccountResourceType}},
+				Required:    true,
+				Description: "Resource ID of the Azure CosmosDB Account.",
+			},
+			"metadata_tag": schema.StringAttribute{
+				Validators:  []validator.String{tagNameValidator{}},
+				Optional:    true,
+				Description: "Name of the account tag the metadata is recorded in, as set in the `metadata_tag` of the resources. Defaults to `" + defaultIpRuleMetadataTag + "`.",
+			},
+			"ip_rules": schema.ListNestedAttribute{
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"ip": schema.StringAttribute{
+							Computed:    true,
+							Description: "IP address or CIDR range allowed by the account.",
+						},
+						"description": schema.StringAttribute{
+							Computed:    true,
+							Description: "Recorded description of the rule. When the rule aggregates several recorded rules, their distinct descriptions joined with `; `. Null when none was recorded.",
+						},
+						"owner": schema.StringAttribute{
+							Computed:    true,
+							Description: "Recorded owner of the rule. When the rule aggregates several recorded rules, their distinct owners joined with `; `. Null when none was recorded.",
+						},
+						"recorded_ips": schema.ListAttribute{
+							ElementType: types.StringType,
+							Computed:    true,
+							Description: "IP rules of the configurations the metadata was recorded for: `ip` itself, or the rules `aggregate` merged into `ip`. Empty when no metadata was recorded.",
+						},
+					},
+				},
+				Computed:    true,
+				Description: "Every IP rule of the CosmosDB account, in the order of the account.",
+			},
+		},
+	}
+}
+
+func (d *CosmosDBIpRulesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config CosmosDBIpRulesDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	cosmosID := config.CosmosDBAccountId.ValueString()
+	parsedId, err := resourceid.ParseAs(cosmosID, cosmosDBAccountResourceType)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("cosmosdb_account_id"), "Invalid resource ID", err.Error())
+		return
+	}
+	cosmo, err := d.client.ReadCosmosDB(ctx, parsedId)
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Could not read CosmosDB", "Failed to read CosmosDB account with ID "+cosmosID, err)
+		return
+	}
+
+	key := config.MetadataTag.ValueString()
+	if config.MetadataTag.IsNull() {
+		key = defaultIpRuleMetadataTag
+	}
+	metadata, err := rulemetadata.Decode(key, cosmo.Tags)
+	if err != nil {
+		resp.Diagnostics.AddAttributeWarning(path.Root("metadata_tag"),
+			"Invalid IP rule metadata",
+			"The description and owner of the IP rules of CosmosDB account "+cosmosID+" can't be read: "+err.Error()+".")
+		metadata = map[string]rulemetadata.Metadata{}
+	}
+
+	rules := []liveIpRuleModel{}
+	for _, ip := range parseCurrentIpRulesFromResponse(cosmo) {
+		recordedIps, m := recordedIpRuleMetadata(ip, metadata)
+		rules = append(rules, liveIpRuleModel{
+			Ip:          types.StringValue(ip),
+			Description: stringOrNull(m.Description),
+			Owner:       stringOrNull(m.Owner),
+			RecordedIps: stringsToList(ctx, recordedIps, &resp.Diagnostics),
+		})
+	}
+	ipRules, diags := types.ListValueFrom(ctx, liveIpRuleObjectType, rules)
+	resp.Diagnostics.Append(diags...)
+	config.IpRules = ipRules
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+}
+
+// recordedIpRuleMetadata returns the metadata recorded for a rule of the account and the configured rules it was
+// recorded for. A rule without metadata of its own may aggregate configured rules: their distinct descriptions and
+// owners are joined.
+func recordedIpRuleMetadata(ip string, metadata map[string]rulemetadata.Metadata) ([]string, rulemetadata.Metadata) {
+	if m, ok := metadata[ip]; ok {
+		return []string{ip}, m
+	}
+	recordedIps := []string{}
+	for recorded := range metadata {
+		if contained, err := cidr.Contains(ip, recorded); err == nil && contained {
+			recordedIps = append(recordedIps, recorded)
+		}
+	}
+	sort.Strings(recordedIps)
+	var descriptions, owners []string
+	for _, recorded := range recordedIps {
+		descriptions = appendDistinct(descriptions, metadata[recorded].Description)
+		owners = appendDistinct(owners, metadata[recorded].Owner)
+	}
+	return recordedIps, rulemetadata.Metadata{Description: strings.Join(descriptions, "; "), Owner: strings.Join(owners, "; ")}
+}
+
+func appendDistinct(values []string, value string) []string {
+	if value == "" {
+		return values
+	}
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+func stringOrNull(value string) types.String {
+	if value == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(value)
+}
@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"terraform-provider-azurermext/internal/client"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ datasource.DataSourceWithConfigure = (*ResourceGraphQueryDataSource)(nil)
+)
+
+type ResourceGraphQueryDataSource struct {
+	client *client.Client
+}
+
+type ResourceGraphQueryDataSourceModel struct {
+	Query              types.String  `tfsdk:"query"`
+	SubscriptionIds    types.List    `tfsdk:"subscription_ids"`
+	ManagementGroupIds types.List    `tfsdk:"management_group_ids"`
+	Rows               types.Dynamic `tfsdk:"rows"`
+	RowsJson           types.String  `tfsdk:"rows_json"`
+}
+
+func NewResourceGraphQueryDataSource() datasource.DataSource {
+	return &ResourceGraphQueryDataSource{}
+}
+
+func (d *ResourceGraphQueryDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_resource_graph_query"
+}
+
+func (d *ResourceGraphQueryDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.client = req.ProviderData.(*client.Client)
+}
+
+func (d *ResourceGraphQueryDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: resourceGraphQueryDescription,
+		Attributes: map[string]schema.Attribute{
+			"query": schema.StringAttribute{
+				Required:    true,
+				Description: "KQL query, e.g. `resources | where type =~ 'microsoft.documentdb/databaseaccounts' | project id, name`.",
+			},
+			"subscription_ids": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Subscriptions to query. When neither `subscription_ids` nor `management_group_ids` is set, every subscription of the provider's tenant the service principal can read is queried.",
+			},
+			"management_group_ids": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Management groups whose subscriptions are queried, by name, e.g. `platform`.",
+			},
+			"rows": schema.DynamicAttribute{
+				Computed:    true,
+				Description: "Every row of the result, as a list of objects with the columns of the query. JSON `null` values are null strings.",
+			},
+			"rows_json": schema.StringAttribute{
+				Computed:    true,
+				Description: "Every row of the result as a JSON array of objects, for `jsondecode`.",
+			},
+		},
+	}
+}
+
+func (d *ResourceGraphQueryDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config ResourceGraphQueryDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	rows, err := d.client.QueryResourceGraph(ctx, client.ResourceGraphQuery{
+		Query:            config.Query.ValueString(),
+		Subscriptions:    listToStrings(config.SubscriptionIds),
+		ManagementGroups: listToStrings(config.ManagementGroupIds),
+	})
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Could not query Resource Graph", "Failed to run the Resource Graph query", err)
+		return
+	}
+
+	values := make([]attr.Value, 0, len(rows))
+	valueTypes := make([]attr.Type, 0, len(rows))
+	for i, row := range rows {
+		value, err := jsonToValue(row)
+		if err != nil {
+			resp.Diagnostics.AddError("Invalid Resource Graph result", fmt.Sprintf("Row %d can't be read: %s", i, err))
+			return
+		}
+		values = append(values, value)
+		valueTypes = append(valueTypes, value.Type(ctx))
+	}
+	tuple, diags := types.TupleValue(valueTypes, values)
+	resp.Diagnostics.Append(diags...)
+	rowsJson, err := json.Marshal(rows)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid Resource Graph result", err.Error())
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	config.Rows = types.DynamicValue(tuple)
+	config.RowsJson = types.StringValue(string(rowsJson))
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+}
+
+// jsonToValue converts a JSON document to a value of the type Terraform's jsondecode would give it: objects and
+// arrays become objects and tuples, so that rows of any shape can be returned.
+func jsonToValue(raw json.RawMessage) (attr.Value, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	return anyToValue(document)
+}
+
+func anyToValue(document any) (attr.Value, error) {
+	switch v := document.(type) {
+	case nil:
+		return types.StringNull(), nil
+	case bool:
+		return types.BoolValue(v), nil
+	case string:
+		return types.StringValue(v), nil
+	case json.Number:
+		number, _, err := big.ParseFloat(v.String(), 10, 512, big.ToNearestEven)
+		if err != nil {
+			return nil, err
+		}
+		return types.NumberValue(number), nil
+	case []any:
+		elementTypes := make([]attr.Type, 0, len(v))
+		elements := make([]attr.Value, 0, len(v))
+		for _, item := range v {
+			element, err := anyToValue(item)
+			if err != nil {
+				return nil, err
+			}
+			elementTypes = append(elementTypes, element.Type(context.Background()))
+			elements = append(elements, element)
+		}
+		return types.TupleValueMust(elementTypes, elements), nil
+	case map[string]any:
+		attributeTypes := make(map[string]attr.Type, len(v))
+		attributes := make(map[string]attr.Value, len(v))
+		for name, item := range v {
+			attribute, err := anyToValue(item)
+			if err != nil {
+				return nil, err
+			}
+			attributeTypes[name] = attribute.Type(context.Background())
+			attributes[name] = attribute
+		}
+		return types.ObjectValueMust(attributeTypes, attributes), nil
+	default:
+		return nil, fmt.Errorf("unexpected JSON value %v", v)
+	}
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"terraform-provider-azurermext/internal/testing/fakearm"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccResourceGraphQueryDataSource_basic(t *testing.T) {
+	server := fakearm.New(t)
+	server.SetPageSize(1)
+	server.SetResourceGraphRows(
+		map[string]any{"id": testCosmosDBAccountId("a"), "tags": map[string]any{"env": "prod"}},
+		map[string]any{"id": testCosmosDBAccountId("b"), "ipRuleCount": 2, "public": true, "kind": nil},
+		map[string]any{"id": testCosmosDBAccountId("c"), "ipRules": []any{"10.0.0.1", "10.0.0.2"}},
+	)
+	const otherSubscriptionId = "00000000-0000-0000-0000-000000000002"
+	const query = "resources | where type =~ 'microsoft.documentdb/databaseaccounts'"
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + fmt.Sprintf(`
+data "azurermext_resource_graph_query" "test" {
+  query            = %q
+  subscription_ids = [%q, %q]
+}
+`, query, testSubscriptionId, otherSubscriptionId),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					// Every page is read, each row keeping its own shape.
+					resource.TestCheckResourceAttr("data.azurermext_resource_graph_query.test", "rows.#", "3"),
+					resource.TestCheckResourceAttr("data.azurermext_resource_graph_query.test", "rows.0.tags.env", "prod"),
+					resource.TestCheckResourceAttr("data.azurermext_resource_graph_query.test", "rows.1.ipRuleCount", "2"),
+					resource.TestCheckResourceAttr("data.azurermext_resource_graph_query.test", "rows.1.public", "true"),
+					resource.TestCheckNoResourceAttr("data.azurermext_resource_graph_query.test", "rows.1.kind"),
+					resource.TestCheckResourceAttr("data.azurermext_resource_graph_query.test", "rows.2.ipRules.#", "2"),
+					resource.TestCheckResourceAttr("data.azurermext_resource_graph_query.test", "rows.2.ipRules.1", "10.0.0.2"),
+					resource.TestCheckResourceAttr("data.azurermext_resource_graph_query.test", "rows_json", fmt.Sprintf(
+						`[{"id":%q,"tags":{"env":"prod"}},{"id":%q,"ipRuleCount":2,"kind":null,"public":true},{"id":%q,"ipRules":["10.0.0.1","10.0.0.2"]}]`,
+						testCosmosDBAccountId("a"), testCosmosDBAccountId("b"), testCosmosDBAccountId("c"))),
+					testCheckResourceGraphQuery(server, query, testSubscriptionId, otherSubscriptionId),
+					testCheckResourceGraphPages(server, 3),
+				),
+			},
+			{
+				// Without subscription_ids the query runs on every subscription of the tenant.
+				Config: server.ProviderConfig() + fmt.Sprintf(`
+data "azurermext_resource_graph_query" "test" {
+  query = %q
+}
+`, query),
+				Check: testCheckResourceGraphQuery(server, query),
+			},
+		},
+	})
+}
+
+// testCheckResourceGraphQuery checks the last query sent to Resource Graph and its subscriptions.
+func testCheckResourceGraphQuery(server *fakearm.Server, query string, subscriptions ...string) func(*terraform.State) error {
+	return func(*terraform.State) error {
+		got := server.LastResourceGraphQuery()
+		if got.Query != query {
+			return fmt.Errorf("got query %q, want %q", got.Query, query)
+		}
+		if !slices.Equal(got.Subscriptions, subscriptions) {
+			return fmt.Errorf("got subscriptions %v, want %v", got.Subscriptions, subscriptions)
+		}
+		return nil
+	}
+}
+
+// testCheckResourceGraphPages checks that Resource Graph was queried at least the given number of times, once per
+// page.
+func testCheckResourceGraphPages(server *fakearm.Server, atLeast int) func(*terraform.State) error {
+	return func(*terraform.State) error {
+		pages := 0
+		for _, request := range server.Requests() {
+			if request.Method == http.MethodPost && strings.EqualFold(request.Path, "/providers/Microsoft.ResourceGraph/resources") {
+				pages++
+			}
+		}
+		if pages < atLeast {
+			return fmt.Errorf("queried Resource Graph %d times, want at least %d", pages, atLeast)
+		}
+		return nil
+	}
+}
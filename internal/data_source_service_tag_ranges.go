@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"context"
+	"terraform-provider-azurermext/internal/cidr"
+	"terraform-provider-azurermext/internal/client"
+	"terraform-provider-azurermext/internal/servicetags"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ datasource.DataSourceWithConfigure = (*ServiceTagRangesDataSource)(nil)
+)
+
+type ServiceTagRangesDataSource struct {
+	client *client.Client
+}
+
+type ServiceTagRangesDataSourceModel struct {
+	ServiceTags     types.List   `tfsdk:"service_tags"`
+	File            types.String `tfsdk:"file"`
+	SubscriptionId  types.String `tfsdk:"subscription_id"`
+	Location        types.String `tfsdk:"location"`
+	Aggregate       types.Bool   `tfsdk:"aggregate"`
+	AddressPrefixes types.List   `tfsdk:"address_prefixes"`
+	ChangeNumber    types.String `tfsdk:"change_number"`
+	Cloud           types.String `tfsdk:"cloud"`
+}
+
+func NewServiceTagRangesDataSource() datasource.DataSource {
+	return &ServiceTagRangesDataSource{}
+}
+
+func (d *ServiceTagRangesDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_service_tag_ranges"
+}
+
+func (d *ServiceTagRangesDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, _ *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.client = req.ProviderData.(*client.Client)
+}
+
+func (d *ServiceTagRangesDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: serviceTagRangesDescription,
+		Attributes: map[string]schema.Attribute{
+			"service_tags": schema.ListAttribute{
+				ElementType: types.StringType,
+				Required:    true,
+				Description: "Names of the service tags, e.g. `AzureCloud.westeurope` or `DataFactory`. Names are case-insensitive.",
+			},
+			"file": schema.StringAttribute{
+				Optional:    true,
+				Description: "Path to a ServiceTags_Public JSON file, as downloaded from the Microsoft Download Center. When unset the tags are read from the serviceTags API, which requires `location`.",
+			},
+			"subscription_id": schema.StringAttribute{
+				Optional:    true,
+				Description: "Subscription whose cloud the serviceTags API returns the tags of. Defaults to the provider's `subscription_id`.",
+			},
+			"location": schema.StringAttribute{
+				Optional:    true,
+				Description: "Location the serviceTags API is called at, e.g. `westeurope`. It only selects the version of the tags: the tags of every region are returned whatever its value.",
+			},
+			"aggregate": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Whether to merge the ranges into the fewest CIDR ranges covering exactly the same addresses, which keeps them under the rule limits of Azure firewalls. Defaults to `true`.",
+			},
+			"address_prefixes": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "IPv4 ranges of the service tags. IPv6 ranges are left out.",
+			},
+			"change_number": schema.StringAttribute{
+				Computed:    true,
+				Description: "Version of the service tags, incremented by Microsoft on every change.",
+			},
+			"cloud": schema.StringAttribute{
+				Computed:    true,
+				Description: "Cloud the service tags belong to, e.g. `Public`.",
+			},
+		},
+	}
+}
+
+func (d *ServiceTagRangesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config ServiceTagRangesDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if config.File.IsNull() && config.Location.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("location"),
+			"Missing location",
+			"The serviceTags API is called at a location, either location or file must be set.",
+		)
+		return
+	}
+
+	tags, err := readServiceTags(ctx, d.client, config.File.ValueString(), config.SubscriptionId.ValueString(), config.Location.ValueString())
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Could not read service tags", "Failed to read the service tags", err)
+		return
+	}
+	names := listToStrings(config.ServiceTags)
+	var prefixes []string
+	if config.Aggregate.IsNull() || config.Aggregate.ValueBool() {
+		prefixes, err = serviceTagIpRules(tags, names)
+	} else {
+		prefixes, err = tags.IPv4Prefixes(names...)
+	}
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("service_tags"), "Invalid service tags", err.Error())
+		return
+	}
+
+	config.AddressPrefixes = stringsToList(ctx, prefixes, &resp.Diagnostics)
+	config.ChangeNumber = types.StringValue(tags.ChangeNumber.String())
+	config.Cloud = types.StringValue(tags.Cloud)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+}
+
+// readServiceTags reads the service tags from file when set, otherwise from the serviceTags API.
+func readServiceTags(ctx context.Context, c *client.Client, file, subscriptionId, location string) (*servicetags.ServiceTags, error) {
+	if file != "" {
+		return servicetags.ReadFile(file)
+	}
+	return c.ReadServiceTags(ctx, subscriptionId, location)
+}
+
+// serviceTagIpRules returns the IPv4 ranges of the named tags merged into the fewest ranges covering the same
+// addresses, since a single tag may hold thousands of ranges.
+func serviceTagIpRules(tags *servicetags.ServiceTags, names []string) ([]string, error) {
+	prefixes, err := tags.IPv4Prefixes(names...)
+	if err != nil {
+		return nil, err
+	}
+	return cidr.Merge(prefixes)
+}
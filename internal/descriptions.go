@@ -6,4 +6,46 @@ const (
 
 	// resource: cosmosdb_mongodb_ip_range_filter
 	cosmosDbIpRangeFilterDescription = "Manages IP rules for a Cosmos DB account. Ignores additional IPs unlike the official resource."
+
+	// resource: cosmosdb_ip_range_filter_bulk
+	cosmosDbIpRangeFilterBulkDescription = "Manages the same IP rules for many Cosmos DB accounts, updated in parallel. Ignores additional IPs unlike the official resource."
+
+	// resource: container_registry_ip_rule_filter
+	containerRegistryIpRuleFilterDescription = "Manages IP rules for a Container Registry. Ignores additional IPs unlike the official resource."
+
+	// resource: eventhub_namespace_ip_rule_filter
+	eventHubNamespaceIpRuleFilterDescription = "Manages IP rules for an Event Hubs Namespace. Ignores additional IPs unlike the official resource."
+
+	// resource: servicebus_namespace_ip_rule_filter
+	serviceBusNamespaceIpRuleFilterDescription = "Manages IP rules for a Service Bus Namespace. Ignores additional IPs unlike the official resource."
+
+	// data source: service_tag_ranges
+	serviceTagRangesDescription = "Returns the IPv4 ranges of Azure service tags, e.g. `AzureCloud.westeurope` or `DataFactory`, read from a ServiceTags_Public JSON file or from the serviceTags API."
+
+	// data source: cosmosdb_ip_rules
+	cosmosDbIpRulesDescription = "Returns the IP rules of a Cosmos DB account with the description and owner recorded by the `azurermext_cosmosdb_ip_range_filter` resources managing them."
+
+	// data source: cosmosdb_accounts
+	cosmosDbAccountsDescription = "Lists the Cosmos DB accounts of subscriptions and resource groups, optionally filtered by tags, e.g. to find the accounts an `azurermext_cosmosdb_ip_range_filter_bulk` should manage."
+
+	// data source: resource_graph_query
+	resourceGraphQueryDescription = "Runs an Azure Resource Graph query, e.g. to find all the public Cosmos DB accounts of many subscriptions in one call, and returns every row."
+
+	// function: cidr_merge
+	cidrMergeFunctionDescription = "Merges IP addresses and CIDR ranges into the fewest CIDR ranges covering exactly the same addresses: duplicates and ranges contained in others are dropped, adjacent ranges are joined. The result is sorted, IPv4 first, and single addresses are written without prefix length."
+
+	// function: cidr_normalize
+	cidrNormalizeFunctionDescription = "Normalizes an IP address or CIDR range the way Azure stores it: host bits of a range are cleared and single addresses are written without prefix length, e.g. `10.0.0.1/24` becomes `10.0.0.0/24` and `10.0.0.1/32` becomes `10.0.0.1`."
+
+	// function: cidr_contains
+	cidrContainsFunctionDescription = "Returns whether every address of an IP address or CIDR range is within a CIDR range."
+
+	// function: cidr_subtract
+	cidrSubtractFunctionDescription = "Returns the fewest CIDR ranges covering the addresses of a list of IP addresses and CIDR ranges which aren't in a second list, e.g. `10.0.0.0/24` minus `10.0.0.0/26` is `10.0.0.64/26` and `10.0.0.128/25`."
+
+	// function: is_private_ip
+	isPrivateIpFunctionDescription = "Returns whether every address of an IP address or CIDR range is private (RFC 1918 or RFC 4193). Private addresses never reach the public endpoint of an Azure resource, so firewall rules allowing them have no effect."
+
+	// function: parse_resource_id
+	parseResourceIdFunctionDescription = "Parses an Azure Resource Manager resource ID into its parts, failing on IDs the provider's resources would reject."
 )
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"terraform-provider-azurermext/internal/client"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// addClientError adds an error diagnostic for an error returned by the client.
+// ARM errors are rendered with their code, target, nested details and request IDs so the diagnostic can be
+// pasted as is in a support ticket. detail describes what was being done, e.g. "Failed to read CosmosDB account ...".
+func addClientError(diags *diag.Diagnostics, summary, detail string, err error) {
+	var authErr *client.AuthenticationError
+	if errors.As(err, &authErr) {
+		addAuthenticationError(diags, detail, authErr)
+		return
+	}
+	var armErr *client.ARMError
+	if !errors.As(err, &armErr) {
+		diags.AddError(summary, detail+": "+err.Error())
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(detail + ".\n\n")
+	if armErr.OperationStatus != "" {
+		b.WriteString("The Azure operation finished with status " + string(armErr.OperationStatus) + ".\n")
+	} else {
+		fmt.Fprintf(&b, "Azure responded with HTTP status %d.\n", armErr.StatusCode)
+	}
+	writeARMErrorDetail(&b, armErr.ARMErrorDetail, "")
+	if hint, ok := armErrorHints[armErr.Code]; ok {
+		b.WriteString("\n" + hint + "\n")
+	}
+	if armErr.RequestID != "" {
+		b.WriteString("\nRequest ID: " + armErr.RequestID)
+	}
+	if armErr.CorrelationRequestID != "" {
+		b.WriteString("\nCorrelation request ID: " + armErr.CorrelationRequestID)
+	}
+	diags.AddError(summary, strings.TrimRight(b.String(), "\n"))
+}
+
+func writeARMErrorDetail(b *strings.Builder, detail client.ARMErrorDetail, indent string) {
+	if detail.Code != "" {
+		b.WriteString(indent + "Code: " + detail.Code + "\n")
+	}
+	if detail.Message != "" {
+		b.WriteString(indent + "Message: " + detail.Message + "\n")
+	}
+	if detail.Target != "" {
+		b.WriteString(indent + "Target: " + detail.Target + "\n")
+	}
+	for _, info := range detail.AdditionalInfo {
+		b.WriteString(indent + "Additional info (" + info.Type + "): " + string(info.Info) + "\n")
+	}
+	for _, nested := range detail.Details {
+		b.WriteString(indent + "Details:\n")
+		writeARMErrorDetail(b, nested, indent+"  ")
+	}
+}
+
+// armErrorHints maps ARM error codes caused by the provider configuration to what the user has to do about them.
+var armErrorHints = map[string]string{
+	"InvalidAuthenticationTokenTenant": "The resource belongs to a subscription of another tenant. Add that tenant to `auxiliary_tenant_ids`/`ARM_AUXILIARY_TENANT_IDS`.",
+}
+
+// aadstsHints maps the most common AADSTS codes to what the user has to do about them.
+var aadstsHints = map[int]string{
+	7000215: "The client secret is invalid. Make sure `client_secret`/`ARM_CLIENT_SECRET` holds the secret value and not the secret ID.",
+	7000222: "The client secret has expired. Create a new secret for the application and update `client_secret`/`ARM_CLIENT_SECRET`.",
+	700016:  "The application was not found in the tenant. Check `client_id`/`ARM_CLIENT_ID` and that `tenant_id`/`ARM_TENANT_ID` is the tenant the service principal lives in.",
+	90002:   "The tenant was not found. Check `tenant_id`/`ARM_TENANT_ID`.",
+	900023:  "The tenant ID is malformed. `tenant_id`/`ARM_TENANT_ID` must be a tenant GUID or a verified domain name.",
+	7000112: "The application is disabled. Ask a tenant administrator to re-enable it.",
+	7000114: "The application is disabled. Ask a tenant administrator to re-enable it.",
+	53003:   "Access was blocked by a Conditional Access policy of the tenant.",
+}
+
+// addAuthenticationError renders an Azure AD token error. Secrets are never part of it, see client.AuthenticationError.
+func addAuthenticationError(diags *diag.Diagnostics, detail string, authErr *client.AuthenticationError) {
+	var b strings.Builder
+	b.WriteString(detail + ": the service principal could not authenticate to Azure AD.\n\n")
+	for _, code := range authErr.AADSTSCodes {
+		if hint, ok := aadstsHints[code]; ok {
+			b.WriteString(hint + "\n\n")
+			break
+		}
+	}
+	fmt.Fprintf(&b, "Azure AD responded with HTTP status %d.\n", authErr.StatusCode)
+	if authErr.ErrorCode != "" {
+		b.WriteString("Error: " + authErr.ErrorCode + "\n")
+	}
+	if authErr.Description != "" {
+		description, _, _ := strings.Cut(authErr.Description, "\r\n")
+		b.WriteString("Description: " + description + "\n")
+	}
+	if authErr.TraceID != "" {
+		b.WriteString("Trace ID: " + authErr.TraceID + "\n")
+	}
+	if authErr.CorrelationID != "" {
+		b.WriteString("Correlation ID: " + authErr.CorrelationID + "\n")
+	}
+	diags.AddError("Azure AD authentication failed", strings.TrimRight(b.String(), "\n"))
+}
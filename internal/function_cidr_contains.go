@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"context"
+	"terraform-provider-azurermext/internal/cidr"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var (
+	_ function.Function = CidrContainsFunction{}
+)
+
+type CidrContainsFunction struct{}
+
+func NewCidrContainsFunction() function.Function {
+	return CidrContainsFunction{}
+}
+
+func (f CidrContainsFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "cidr_contains"
+}
+
+func (f CidrContainsFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Check whether a CIDR range contains an IP address or range",
+		Description: cidrContainsFunctionDescription,
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "cidr",
+				Description: "CIDR range to look into, e.g. `10.0.0.0/16`.",
+			},
+			function.StringParameter{
+				Name:        "ip",
+				Description: "IP address or CIDR range to look for, e.g. `10.0.1.7` or `10.0.1.0/24`.",
+			},
+		},
+		Return: function.BoolReturn{},
+	}
+}
+
+func (f CidrContainsFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var prefix, value string
+	resp.Error = req.Arguments.Get(ctx, &prefix, &value)
+	if resp.Error != nil {
+		return
+	}
+	if _, err := cidr.ParsePrefix(prefix); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	contains, err := cidr.Contains(prefix, value)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, err.Error())
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, contains)
+}
@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestCidrContainsFunction(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		value  string
+		want   bool
+	}{
+		{name: "address", prefix: "10.0.0.0/24", value: "10.0.0.7", want: true},
+		{name: "range", prefix: "10.0.0.0/16", value: "10.0.3.0/24", want: true},
+		{name: "wider range", prefix: "10.0.3.0/24", value: "10.0.0.0/16"},
+		{name: "other family", prefix: "::/0", value: "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testRunFunction(t, NewCidrContainsFunction(), types.StringValue(tt.prefix), types.StringValue(tt.value))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := types.BoolValue(tt.want); !got.Equal(want) {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+
+	t.Run("invalid cidr", func(t *testing.T) {
+		_, err := testRunFunction(t, NewCidrContainsFunction(), types.StringValue("10.0.0.0/40"), types.StringValue("10.0.0.1"))
+		testCheckFuncError(t, err, 0, `"10.0.0.0/40"`)
+	})
+	t.Run("invalid ip", func(t *testing.T) {
+		_, err := testRunFunction(t, NewCidrContainsFunction(), types.StringValue("10.0.0.0/24"), types.StringValue("10.0.0.x"))
+		testCheckFuncError(t, err, 1, `"10.0.0.x"`)
+	})
+}
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"context"
+	"terraform-provider-azurermext/internal/cidr"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = CidrMergeFunction{}
+)
+
+type CidrMergeFunction struct{}
+
+func NewCidrMergeFunction() function.Function {
+	return CidrMergeFunction{}
+}
+
+func (f CidrMergeFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "cidr_merge"
+}
+
+func (f CidrMergeFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Merge IP addresses and CIDR ranges into the fewest CIDR ranges",
+		Description: cidrMergeFunctionDescription,
+		Parameters: []function.Parameter{
+			function.ListParameter{
+				ElementType: types.StringType,
+				Name:        "cidrs",
+				Description: "IPv4 or IPv6 addresses and CIDR ranges, e.g. `10.0.0.1` or `10.0.0.0/24`.",
+			},
+		},
+		Return: function.ListReturn{ElementType: types.StringType},
+	}
+}
+
+func (f CidrMergeFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var values []string
+	resp.Error = req.Arguments.Get(ctx, &values)
+	if resp.Error != nil {
+		return
+	}
+	merged, err := cidr.Merge(values)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, merged)
+}
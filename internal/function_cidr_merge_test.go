@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// testStringList returns a list value of values.
+func testStringList(values ...string) types.List {
+	elements := []attr.Value{}
+	for _, value := range values {
+		elements = append(elements, types.StringValue(value))
+	}
+	return types.ListValueMust(types.StringType, elements)
+}
+
+func TestCidrMergeFunction(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   []string
+	}{
+		{name: "empty", values: nil, want: nil},
+		{name: "siblings and contained address", values: []string{"10.0.0.0/25", "10.0.0.128/25", "10.0.0.7"}, want: []string{"10.0.0.0/24"}},
+		{name: "IPv4 first", values: []string{"2001:db8::1", "10.0.0.1/32"}, want: []string{"10.0.0.1", "2001:db8::1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testRunFunction(t, NewCidrMergeFunction(), testStringList(tt.values...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := testStringList(tt.want...); !got.Equal(want) {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := testRunFunction(t, NewCidrMergeFunction(), testStringList("10.0.0.1", "10.0.0.300"))
+		testCheckFuncError(t, err, 0, `"10.0.0.300"`)
+	})
+}
@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"context"
+	"terraform-provider-azurermext/internal/cidr"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var (
+	_ function.Function = CidrNormalizeFunction{}
+)
+
+type CidrNormalizeFunction struct{}
+
+func NewCidrNormalizeFunction() function.Function {
+	return CidrNormalizeFunction{}
+}
+
+func (f CidrNormalizeFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "cidr_normalize"
+}
+
+func (f CidrNormalizeFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Normalize an IP address or CIDR range",
+		Description: cidrNormalizeFunctionDescription,
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "cidr",
+				Description: "IPv4 or IPv6 address or CIDR range, e.g. `10.0.0.1` or `10.0.0.0/24`.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f CidrNormalizeFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var value string
+	resp.Error = req.Arguments.Get(ctx, &value)
+	if resp.Error != nil {
+		return
+	}
+	normalized, err := cidr.Normalize(value)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, normalized)
+}
@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestCidrNormalizeFunction(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "10.0.0.1", want: "10.0.0.1"},
+		{value: "10.0.0.1/32", want: "10.0.0.1"},
+		{value: "10.0.0.1/24", want: "10.0.0.0/24"},
+		{value: "2001:db8::1/64", want: "2001:db8::/64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := testRunFunction(t, NewCidrNormalizeFunction(), types.StringValue(tt.value))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := types.StringValue(tt.want); !got.Equal(want) {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := testRunFunction(t, NewCidrNormalizeFunction(), types.StringValue("10.0.0.0/33"))
+		testCheckFuncError(t, err, 0, `"10.0.0.0/33" is neither an IP address nor a CIDR range`)
+	})
+}
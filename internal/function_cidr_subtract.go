@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"context"
+	"terraform-provider-azurermext/internal/cidr"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = CidrSubtractFunction{}
+)
+
+type CidrSubtractFunction struct{}
+
+func NewCidrSubtractFunction() function.Function {
+	return CidrSubtractFunction{}
+}
+
+func (f CidrSubtractFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "cidr_subtract"
+}
+
+func (f CidrSubtractFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Remove IP addresses and CIDR ranges from a list of CIDR ranges",
+		Description: cidrSubtractFunctionDescription,
+		Parameters: []function.Parameter{
+			function.ListParameter{
+				ElementType: types.StringType,
+				Name:        "cidrs",
+				Description: "IPv4 or IPv6 addresses and CIDR ranges to remove addresses from.",
+			},
+			function.ListParameter{
+				ElementType: types.StringType,
+				Name:        "excluded",
+				Description: "IPv4 or IPv6 addresses and CIDR ranges to remove.",
+			},
+		},
+		Return: function.ListReturn{ElementType: types.StringType},
+	}
+}
+
+func (f CidrSubtractFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var values, excluded []string
+	resp.Error = req.Arguments.Get(ctx, &values, &excluded)
+	if resp.Error != nil {
+		return
+	}
+	for position, list := range [][]string{values, excluded} {
+		for _, value := range list {
+			if _, err := cidr.ParsePrefix(value); err != nil {
+				resp.Error = function.NewArgumentFuncError(int64(position), err.Error())
+				return
+			}
+		}
+	}
+	remaining, err := cidr.Subtract(values, excluded)
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, remaining)
+}
@@ -0,0 +1,36 @@
+package internal
+
+import "testing"
+
+func TestCidrSubtractFunction(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		excluded []string
+		want     []string
+	}{
+		{name: "split", values: []string{"10.0.0.0/24"}, excluded: []string{"10.0.0.0/26"}, want: []string{"10.0.0.64/26", "10.0.0.128/25"}},
+		{name: "everything", values: []string{"10.0.0.1"}, excluded: []string{"10.0.0.0/24"}, want: nil},
+		{name: "nothing", values: []string{"10.0.0.0/24"}, excluded: nil, want: []string{"10.0.0.0/24"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testRunFunction(t, NewCidrSubtractFunction(), testStringList(tt.values...), testStringList(tt.excluded...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := testStringList(tt.want...); !got.Equal(want) {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+
+	t.Run("invalid cidrs", func(t *testing.T) {
+		_, err := testRunFunction(t, NewCidrSubtractFunction(), testStringList("10.0.0.0/24", "x"), testStringList())
+		testCheckFuncError(t, err, 0, `"x"`)
+	})
+	t.Run("invalid excluded", func(t *testing.T) {
+		_, err := testRunFunction(t, NewCidrSubtractFunction(), testStringList("10.0.0.0/24"), testStringList("10.0.0.0/-1"))
+		testCheckFuncError(t, err, 1, `"10.0.0.0/-1"`)
+	})
+}
@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"context"
+	"terraform-provider-azurermext/internal/cidr"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var (
+	_ function.Function = IsPrivateIpFunction{}
+)
+
+type IsPrivateIpFunction struct{}
+
+func NewIsPrivateIpFunction() function.Function {
+	return IsPrivateIpFunction{}
+}
+
+func (f IsPrivateIpFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "is_private_ip"
+}
+
+func (f IsPrivateIpFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Check whether an IP address or CIDR range is private",
+		Description: isPrivateIpFunctionDescription,
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "ip",
+				Description: "IPv4 or IPv6 address or CIDR range, e.g. `10.0.0.1` or `10.0.0.0/24`.",
+			},
+		},
+		Return: function.BoolReturn{},
+	}
+}
+
+func (f IsPrivateIpFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var value string
+	resp.Error = req.Arguments.Get(ctx, &value)
+	if resp.Error != nil {
+		return
+	}
+	private, err := cidr.IsPrivate(value)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, private)
+}
@@ -0,0 +1,36 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestIsPrivateIpFunction(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{value: "10.1.2.3", want: true},
+		{value: "192.168.0.0/16", want: true},
+		{value: "fd00::1", want: true},
+		{value: "8.8.8.8"},
+		{value: "10.0.0.0/7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := testRunFunction(t, NewIsPrivateIpFunction(), types.StringValue(tt.value))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := types.BoolValue(tt.want); !got.Equal(want) {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := testRunFunction(t, NewIsPrivateIpFunction(), types.StringValue("localhost"))
+		testCheckFuncError(t, err, 0, `"localhost" is neither an IP address nor a CIDR range`)
+	})
+}
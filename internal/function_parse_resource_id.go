@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"context"
+	"terraform-provider-azurermext/internal/resourceid"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ function.Function = ParseResourceIdFunction{}
+)
+
+var parsedResourceIdAttributeTypes = map[string]attr.Type{
+	"subscription_id":     types.StringType,
+	"resource_group_name": types.StringType,
+	"provider_namespace":  types.StringType,
+	"resource_type":       types.StringType,
+	"name":                types.StringType,
+	"parent_id":           types.StringType,
+}
+
+type ParseResourceIdFunction struct{}
+
+func NewParseResourceIdFunction() function.Function {
+	return ParseResourceIdFunction{}
+}
+
+func (f ParseResourceIdFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "parse_resource_id"
+}
+
+func (f ParseResourceIdFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Parse an Azure Resource Manager resource ID",
+		Description: parseResourceIdFunctionDescription,
+		MarkdownDescription: parseResourceIdFunctionDescription + " The returned object has the attributes `subscription_id`, `resource_group_name`, " +
+			"`provider_namespace`, e.g. `Microsoft.DocumentDB`, `resource_type`, e.g. `Microsoft.DocumentDB/databaseAccounts`, `name` and `parent_id`. " +
+			"The attributes an ID doesn't have, e.g. `resource_type` for a resource group ID, are null.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "id",
+				Description: "Resource ID, e.g. `/subscriptions/{subscription}/resourceGroups/{group}/providers/Microsoft.DocumentDB/databaseAccounts/{name}`.",
+			},
+		},
+		Return: function.ObjectReturn{AttributeTypes: parsedResourceIdAttributeTypes},
+	}
+}
+
+func (f ParseResourceIdFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var value string
+	resp.Error = req.Arguments.Get(ctx, &value)
+	if resp.Error != nil {
+		return
+	}
+	id, err := resourceid.Parse(value)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	optional := func(value string) types.String {
+		if value == "" {
+			return types.StringNull()
+		}
+		return types.StringValue(value)
+	}
+	parentId := types.StringNull()
+	if id.ResourceGroup != "" {
+		parentId = types.StringValue(id.Parent().String())
+	}
+	name := types.StringNull()
+	if id.Provider != "" {
+		name = types.StringValue(id.Name())
+	}
+	parsed, diags := types.ObjectValue(parsedResourceIdAttributeTypes, map[string]attr.Value{
+		"subscription_id":     types.StringValue(id.SubscriptionID),
+		"resource_group_name": optional(id.ResourceGroup),
+		"provider_namespace":  optional(id.Provider),
+		"resource_type":       optional(id.ResourceType()),
+		"name":                name,
+		"parent_id":           parentId,
+	})
+	resp.Error = function.FuncErrorFromDiags(ctx, diags)
+	if resp.Error != nil {
+		return
+	}
+	resp.Error = resp.Result.Set(ctx, parsed)
+}
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestParseResourceIdFunction(t *testing.T) {
+	resourceGroupId := "/subscriptions/" + testSubscriptionId + "/resourceGroups/rg"
+	namespaceId := resourceGroupId + "/providers/Microsoft.EventHub/namespaces/ns"
+	tests := []struct {
+		name string
+		id   string
+		want map[string]attr.Value
+	}{
+		{
+			name: "subscription",
+			id:   "/subscriptions/" + testSubscriptionId,
+			want: map[string]attr.Value{
+				"subscription_id":     types.StringValue(testSubscriptionId),
+				"resource_group_name": types.StringNull(),
+				"provider_namespace":  types.StringNull(),
+				"resource_type":       types.StringNull(),
+				"name":                types.StringNull(),
+				"parent_id":           types.StringNull(),
+			},
+		},
+		{
+			name: "resource group",
+			id:   resourceGroupId,
+			want: map[string]attr.Value{
+				"subscription_id":     types.StringValue(testSubscriptionId),
+				"resource_group_name": types.StringValue("rg"),
+				"provider_namespace":  types.StringNull(),
+				"resource_type":       types.StringNull(),
+				"name":                types.StringNull(),
+				"parent_id":           types.StringValue("/subscriptions/" + testSubscriptionId),
+			},
+		},
+		{
+			name: "resource",
+			id:   testCosmosDBAccountId("account"),
+			want: map[string]attr.Value{
+				"subscription_id":     types.StringValue(testSubscriptionId),
+				"resource_group_name": types.StringValue("rg"),
+				"provider_namespace":  types.StringValue("Microsoft.DocumentDB"),
+				"resource_type":       types.StringValue("Microsoft.DocumentDB/databaseAccounts"),
+				"name":                types.StringValue("account"),
+				"parent_id":           types.StringValue(resourceGroupId),
+			},
+		},
+		{
+			name: "nested resource",
+			id:   namespaceId + "/networkRuleSets/default",
+			want: map[string]attr.Value{
+				"subscription_id":     types.StringValue(testSubscriptionId),
+				"resource_group_name": types.StringValue("rg"),
+				"provider_namespace":  types.StringValue("Microsoft.EventHub"),
+				"resource_type":       types.StringValue("Microsoft.EventHub/namespaces/networkRuleSets"),
+				"name":                types.StringValue("default"),
+				"parent_id":           types.StringValue(namespaceId),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testRunFunction(t, NewParseResourceIdFunction(), types.StringValue(tt.id))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := types.ObjectValueMust(parsedResourceIdAttributeTypes, tt.want); !got.Equal(want) {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := testRunFunction(t, NewParseResourceIdFunction(), types.StringValue(testCosmosDBAccountId("account")+"/sqlDatabases"))
+		testCheckFuncError(t, err, 0, "expected {type}/{name} pairs")
+	})
+}
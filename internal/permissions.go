@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"terraform-provider-azurermext/internal/client"
+	"terraform-provider-azurermext/internal/resourceid"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// shouldCheckPermissions reports whether a plan will write to Azure and check_permissions is enabled.
+// Destroying is skipped since the resources don't write anything on delete.
+func shouldCheckPermissions(c *client.Client, req resource.ModifyPlanRequest) bool {
+	return c != nil && c.PermissionCheckEnabled() && !req.Plan.Raw.IsNull() && !req.Plan.Raw.Equal(req.State.Raw)
+}
+
+// checkPermissions adds an error on targetAttribute when the provider's principal lacks any of actions on the
+// target resource, so that missing role assignments are reported by `terraform plan` instead of a 403 mid-apply.
+// Failing to read the permissions only warns, the apply will tell for sure.
+func checkPermissions(ctx context.Context, c *client.Client, plan tfsdk.Plan, targetAttribute, resourceType string, actions []string, diags *diag.Diagnostics) {
+	targetPath := path.Root(targetAttribute)
+	var targetId types.String
+	diags.Append(plan.GetAttribute(ctx, targetPath, &targetId)...)
+	if diags.HasError() || targetId.IsUnknown() || targetId.IsNull() {
+		// Unknown until the target resource is created, there's nothing to check yet.
+		return
+	}
+	id, err := resourceid.ParseAs(targetId.ValueString(), resourceType)
+	if err != nil {
+		// Reported by the attribute validator.
+		return
+	}
+
+	missing, err := c.MissingPermissions(ctx, id, actions...)
+	if err != nil {
+		var armErr *client.ARMError
+		if errors.As(err, &armErr) && armErr.StatusCode == http.StatusNotFound {
+			return
+		}
+		diags.AddAttributeWarning(targetPath, "Could not check permissions",
+			"Failed to read the permissions of the service principal on "+targetId.ValueString()+": "+err.Error())
+		return
+	}
+	if len(missing) != 0 {
+		diags.AddAttributeError(targetPath, "Missing permissions",
+			"The service principal isn't allowed to perform the following actions on "+targetId.ValueString()+":\n\n  - "+
+				strings.Join(missing, "\n  - ")+
+				"\n\nAssign it a role granting them, e.g. Contributor, or disable `check_permissions` in the provider configuration.")
+	}
+}
@@ -2,10 +2,16 @@ package internal
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"strings"
 	"terraform-provider-azurermext/internal/client"
+	"terraform-provider-azurermext/internal/resourceid"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -13,16 +19,43 @@ import (
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
+var (
+	_ provider.ProviderWithFunctions = (*azureRMExtProvider)(nil)
+)
+
 func NewProvider() provider.Provider {
 	return &azureRMExtProvider{}
 }
 
-type azureRMExtProvider struct{}
+// NewProviderWithClientOptions returns a provider factory whose client is built with extra options.
+// Acceptance tests use it to point the provider at an internal/testing/fakearm server.
+func NewProviderWithClientOptions(opts ...client.Option) func() provider.Provider {
+	return func() provider.Provider {
+		return &azureRMExtProvider{clientOptions: opts}
+	}
+}
+
+// defaultMaxConcurrentUpdates matches the default parallelism of Terraform.
+const defaultMaxConcurrentUpdates = 10
+
+type azureRMExtProvider struct {
+	clientOptions []client.Option
+}
 
 type azureRMExtProviderModel struct {
-	TenantId     types.String `tfsdk:"tenant_id"`
-	ClientId     types.String `tfsdk:"client_id"`
-	ClientSecret types.String `tfsdk:"client_secret"`
+	TenantId             types.String `tfsdk:"tenant_id"`
+	ClientId             types.String `tfsdk:"client_id"`
+	ClientSecret         types.String `tfsdk:"client_secret"`
+	SubscriptionId       types.String `tfsdk:"subscription_id"`
+	AuxiliaryTenantIds   types.List   `tfsdk:"auxiliary_tenant_ids"`
+	ProxyUrl             types.String `tfsdk:"proxy_url"`
+	CACertificatesFile   types.String `tfsdk:"ca_certificates_file"`
+	RequestTimeout       types.String `tfsdk:"request_timeout"`
+	DisableKeepalives    types.Bool   `tfsdk:"disable_keepalives"`
+	TokenCacheEnabled    types.Bool   `tfsdk:"token_cache_enabled"`
+	TokenCacheDir        types.String `tfsdk:"token_cache_dir"`
+	CheckPermissions     types.Bool   `tfsdk:"check_permissions"`
+	MaxConcurrentUpdates types.Int64  `tfsdk:"max_concurrent_updates"`
 }
 
 // Metadata returns the provider type name.
@@ -48,6 +81,47 @@ func (p *azureRMExtProvider) Schema(_ context.Context, _ provider.SchemaRequest,
 				Optional:    true,
 				Description: "Service Principal Client Secret.",
 			},
+			"subscription_id": schema.StringAttribute{
+				Optional:    true,
+				Description: "Default subscription ID, used by data sources listing resources. Also available as environment variable `ARM_SUBSCRIPTION_ID`.",
+			},
+			"auxiliary_tenant_ids": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Up to 3 tenants, besides `tenant_id`, the service principal can authenticate to. Resources in subscriptions of these tenants are managed with a token of their tenant, which is detected automatically. Also available as environment variable `ARM_AUXILIARY_TENANT_IDS`, separated by `;`.",
+			},
+			"proxy_url": schema.StringAttribute{
+				Optional:    true,
+				Description: "URL of the HTTP proxy used for every request to Azure. Defaults to the `HTTPS_PROXY` environment variable. `NO_PROXY` is always honoured.",
+			},
+			"ca_certificates_file": schema.StringAttribute{
+				Optional:    true,
+				Description: "Path to a PEM file of CA certificates trusted in addition to the system ones, e.g. the CA of a TLS-inspecting proxy.",
+			},
+			"request_timeout": schema.StringAttribute{
+				Optional:    true,
+				Description: "Timeout of every single HTTP request to Azure as a Go duration, e.g. `30s` or `2m`. Unset means no timeout.",
+			},
+			"disable_keepalives": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Disable HTTP keep-alives, opening a new connection for every request.",
+			},
+			"check_permissions": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Check at plan time that the service principal is granted the actions each resource needs on its target, reporting missing role assignments before applying. Costs one extra request per changed resource. Defaults to `false`.",
+			},
+			"max_concurrent_updates": schema.Int64Attribute{
+				Optional:    true,
+				Description: fmt.Sprintf("Maximum number of firewall updates run at once by all the resources of the provider, e.g. the accounts of an `azurermext_cosmosdb_ip_range_filter_bulk`. CosmosDB account updates take several minutes each, more parallel updates finish sooner but are more likely to be throttled by Azure. Defaults to `%d`.", defaultMaxConcurrentUpdates),
+			},
+			"token_cache_enabled": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Cache Azure AD tokens on disk, encrypted with the client secret, so that successive Terraform runs and provider processes share them. Defaults to `false`.",
+			},
+			"token_cache_dir": schema.StringAttribute{
+				Optional:    true,
+				Description: "Directory of the token cache. Defaults to `terraform-provider-azurermext/tokens` in the user cache directory, e.g. `~/.cache` on Linux.",
+			},
 		},
 	}
 }
@@ -105,7 +179,105 @@ func (p *azureRMExtProvider) Configure(ctx context.Context, req provider.Configu
 		return
 	}
 
-	client_ := client.New(clientId, clientSecret, tenantId)
+	subscriptionId := os.Getenv("ARM_SUBSCRIPTION_ID")
+	if !config.SubscriptionId.IsNull() {
+		subscriptionId = config.SubscriptionId.ValueString()
+	}
+	if subscriptionId != "" {
+		if _, err := resourceid.Parse("/subscriptions/" + subscriptionId); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("subscription_id"),
+				"Invalid subscription ID",
+				"Subscription ID must be a GUID, got \""+subscriptionId+"\".",
+			)
+			return
+		}
+	}
+	var auxiliaryTenantIds []string
+	if config.AuxiliaryTenantIds.IsNull() {
+		for _, tenantId := range strings.Split(os.Getenv("ARM_AUXILIARY_TENANT_IDS"), ";") {
+			if tenantId = strings.TrimSpace(tenantId); tenantId != "" {
+				auxiliaryTenantIds = append(auxiliaryTenantIds, tenantId)
+			}
+		}
+	} else {
+		resp.Diagnostics.Append(config.AuxiliaryTenantIds.ElementsAs(ctx, &auxiliaryTenantIds, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+	if len(auxiliaryTenantIds) > client.MaxAuxiliaryTenants {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("auxiliary_tenant_ids"),
+			"Too many auxiliary tenants",
+			fmt.Sprintf("Azure Resource Manager accepts at most %d auxiliary tenants, got %d.", client.MaxAuxiliaryTenants, len(auxiliaryTenantIds)),
+		)
+		return
+	}
+
+	transportConfig := client.TransportConfig{
+		ProxyURL:           config.ProxyUrl.ValueString(),
+		CACertificatesFile: config.CACertificatesFile.ValueString(),
+		DisableKeepAlives:  config.DisableKeepalives.ValueBool(),
+	}
+	if !config.RequestTimeout.IsNull() {
+		timeout, err := time.ParseDuration(config.RequestTimeout.ValueString())
+		if err != nil || timeout <= 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("request_timeout"),
+				"Invalid request timeout",
+				"Request timeout must be a positive duration such as `30s` or `2m`, got "+config.RequestTimeout.String()+".",
+			)
+			return
+		}
+		transportConfig.RequestTimeout = timeout
+	}
+	httpClient, err := client.NewHTTPClient(transportConfig)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid network configuration",
+			"Could not configure the HTTP client: "+err.Error(),
+		)
+		return
+	}
+
+	maxConcurrentUpdates := int64(defaultMaxConcurrentUpdates)
+	if !config.MaxConcurrentUpdates.IsNull() {
+		maxConcurrentUpdates = config.MaxConcurrentUpdates.ValueInt64()
+		if maxConcurrentUpdates < 1 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("max_concurrent_updates"),
+				"Invalid maximum of concurrent updates",
+				"At least one update must be allowed at once, got "+config.MaxConcurrentUpdates.String()+".",
+			)
+			return
+		}
+	}
+
+	clientOptions := []client.Option{
+		client.WithHTTPClient(httpClient),
+		client.WithSubscriptionID(subscriptionId),
+		client.WithAuxiliaryTenantIDs(auxiliaryTenantIds...),
+		client.WithPermissionCheck(config.CheckPermissions.ValueBool()),
+		client.WithMaxConcurrentUpdates(int(maxConcurrentUpdates)),
+	}
+	if config.TokenCacheEnabled.ValueBool() {
+		cacheDir := config.TokenCacheDir.ValueString()
+		if cacheDir == "" {
+			cacheDir, err = client.DefaultTokenCacheDir()
+			if err != nil {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("token_cache_dir"),
+					"Missing token cache directory",
+					"The user cache directory is unknown, token_cache_dir must be set: "+err.Error(),
+				)
+				return
+			}
+		}
+		clientOptions = append(clientOptions, client.WithTokenCache(client.NewFileTokenCache(cacheDir, clientSecret)))
+	}
+	clientOptions = append(clientOptions, p.clientOptions...)
+	client_ := client.New(clientId, clientSecret, tenantId, clientOptions...)
 	resp.DataSourceData = client_
 	resp.ResourceData = client_
 
@@ -114,12 +286,33 @@ func (p *azureRMExtProvider) Configure(ctx context.Context, req provider.Configu
 
 // DataSources defines the data sources implemented in the provider.
 func (p *azureRMExtProvider) DataSources(_ context.Context) []func() datasource.DataSource {
-	return []func() datasource.DataSource{}
+	return []func() datasource.DataSource{
+		NewServiceTagRangesDataSource,
+		NewCosmosDBIpRulesDataSource,
+		NewCosmosDBAccountsDataSource,
+		NewResourceGraphQueryDataSource,
+	}
 }
 
 // Resources defines the resources implemented in the provider.
 func (p *azureRMExtProvider) Resources(_ context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewCosmosDBMongoDBIpFilterResource,
+		NewCosmosDBIpFilterBulkResource,
+		NewContainerRegistryIpRuleFilterResource,
+		NewEventHubNamespaceIpRuleFilterResource,
+		NewServiceBusNamespaceIpRuleFilterResource,
+	}
+}
+
+// Functions defines the provider-defined functions, called as `provider::azurermext::<name>`.
+func (p *azureRMExtProvider) Functions(_ context.Context) []func() function.Function {
+	return []func() function.Function{
+		NewCidrNormalizeFunction,
+		NewCidrContainsFunction,
+		NewCidrMergeFunction,
+		NewCidrSubtractFunction,
+		NewIsPrivateIpFunction,
+		NewParseResourceIdFunction,
 	}
 }
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"terraform-provider-azurermext/internal/testing/fakearm"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+// testSubscriptionId is the subscription of the resources created in the fake.
+const testSubscriptionId = "00000000-0000-0000-0000-000000000001"
+
+// testProviderFactories returns provider factories for resource.UnitTest pointing the provider at server, so the
+// acceptance tests run without Azure credentials.
+func testProviderFactories(server *fakearm.Server) map[string]func() (tfprotov6.ProviderServer, error) {
+	return map[string]func() (tfprotov6.ProviderServer, error){
+		"azurermext": providerserver.NewProtocol6WithError(NewProviderWithClientOptions(server.ClientOptions()...)()),
+	}
+}
+
+func testCosmosDBAccountId(name string) string {
+	return "/subscriptions/" + testSubscriptionId + "/resourceGroups/rg/providers/Microsoft.DocumentDB/databaseAccounts/" + name
+}
+
+// testCheckCosmosDBIpRules checks the IP rules of a fake CosmosDB account, in order.
+func testCheckCosmosDBIpRules(server *fakearm.Server, accountId string, want ...string) func(*terraform.State) error {
+	return func(*terraform.State) error {
+		account, ok := server.CosmosDBAccount(accountId)
+		if !ok {
+			return fmt.Errorf("CosmosDB account %s not found", accountId)
+		}
+		if !slices.Equal(account.IpRules, want) {
+			return fmt.Errorf("CosmosDB account %s has IP rules %v, want %v", accountId, account.IpRules, want)
+		}
+		return nil
+	}
+}
+
+// testRunFunction calls a provider function the way the framework does, since the functions require Terraform 1.8
+// which the acceptance tests don't.
+func testRunFunction(t *testing.T, f function.Function, args ...attr.Value) (attr.Value, *function.FuncError) {
+	t.Helper()
+	ctx := context.Background()
+	var definition function.DefinitionResponse
+	f.Definition(ctx, function.DefinitionRequest{}, &definition)
+	if definition.Diagnostics.HasError() {
+		t.Fatal(definition.Diagnostics)
+	}
+	if len(args) != len(definition.Definition.Parameters) {
+		t.Fatalf("got %d arguments, want %d", len(args), len(definition.Definition.Parameters))
+	}
+	result, err := definition.Definition.Return.NewResultData(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := function.RunResponse{Result: result}
+	f.Run(ctx, function.RunRequest{Arguments: function.NewArgumentsData(args)}, &resp)
+	return resp.Result.Value(), resp.Error
+}
+
+// testCheckFuncError checks that err is an error about the argument at position, or about no argument when position
+// is -1, containing text.
+func testCheckFuncError(t *testing.T, err *function.FuncError, position int64, text string) {
+	t.Helper()
+	switch {
+	case err == nil:
+		t.Fatalf("got no error, want %q", text)
+	case !strings.Contains(err.Text, text):
+		t.Errorf("got error %q, want it to contain %q", err.Text, text)
+	case position == -1 && err.FunctionArgument != nil:
+		t.Errorf("got an error about argument %d, want an error about no argument", *err.FunctionArgument)
+	case position != -1 && (err.FunctionArgument == nil || *err.FunctionArgument != position):
+		t.Errorf("got error %v, want an error about argument %d", err, position)
+	}
+}
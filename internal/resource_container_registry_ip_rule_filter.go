@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"terraform-provider-azurermext/internal/client"
+	"terraform-provider-azurermext/internal/resourceid"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+)
+
+func NewContainerRegistryIpRuleFilterResource() resource.Resource {
+	return &ipRuleFilterResource{service: ipRuleFilterService{
+		typeName:          "_container_registry_ip_rule_filter",
+		description:       containerRegistryIpRuleFilterDescription,
+		targetAttribute:   "container_registry_id",
+		targetDescription: "Resource ID of the Azure Container Registry.",
+		targetType:        "Microsoft.ContainerRegistry/registries",
+		displayName:       "Container Registry",
+		requiredActions:   []string{"Microsoft.ContainerRegistry/registries/read", "Microsoft.ContainerRegistry/registries/write"},
+		newAdapter: func(c *client.Client, registryId string) ipRuleAdapter {
+			return &containerRegistryIpRuleAdapter{ipRuleAdapterBase: ipRuleAdapterBase{client: c}, registryId: registryId}
+		},
+	}}
+}
+
+type containerRegistryIpRuleAdapter struct {
+	ipRuleAdapterBase
+	registryId    string
+	parsedId      resourceid.ID
+	defaultAction client.NetworkRuleAction
+}
+
+func (a *containerRegistryIpRuleAdapter) Read(ctx context.Context) ([]string, error) {
+	parsedId, err := resourceid.ParseAs(a.registryId, "Microsoft.ContainerRegistry/registries")
+	if err != nil {
+		return nil, err
+	}
+	registry, err := a.client.ReadContainerRegistry(ctx, parsedId)
+	if err != nil {
+		return nil, err
+	}
+	if registry.Properties == nil {
+		return nil, fmt.Errorf("Container Registry %s has no properties", a.registryId)
+	}
+	a.parsedId = parsedId
+	ruleSet := client.ContainerRegistryNetworkRuleSet{DefaultAction: client.NetworkRuleActionAllow}
+	if registry.Properties.NetworkRuleSet != nil {
+		ruleSet = *registry.Properties.NetworkRuleSet
+	}
+	a.id = registry.ID
+	a.public = registry.Properties.PublicNetworkAccess.IsEnabled()
+	a.defaultAction = ruleSet.DefaultAction
+
+	ipRules := make([]string, 0, len(ruleSet.IpRules))
+	for _, rule := range ruleSet.IpRules {
+		if rule.Value != "" {
+			ipRules = append(ipRules, rule.Value)
+		}
+	}
+	return ipRules, nil
+}
+
+func (a *containerRegistryIpRuleAdapter) Write(ctx context.Context, rules []string) (err error) {
+	a.operation, err = a.client.UpdateContainerRegistryIpRules(ctx, a.parsedId, a.defaultAction, rules)
+	return err
+}
@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"terraform-provider-azurermext/internal/testing/fakearm"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccContainerRegistryIpRuleFilter_basic(t *testing.T) {
+	server := fakearm.New(t)
+	registryId := "/subscriptions/" + testSubscriptionId + "/resourceGroups/rg/providers/Microsoft.ContainerRegistry/registries/basic"
+	server.AddContainerRegistry(registryId, []string{"1.1.1.1"})
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + testContainerRegistryIpRuleFilterConfig(registryId, `"10.0.0.1"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckContainerRegistryIpRules(server, registryId, "1.1.1.1", "10.0.0.1"),
+					resource.TestCheckResourceAttr("azurermext_container_registry_ip_rule_filter.test", "ip_rules.#", "1"),
+				),
+			},
+			{
+				Config: server.ProviderConfig() + testContainerRegistryIpRuleFilterConfig(registryId, `"10.0.0.2"`),
+				Check:  testCheckContainerRegistryIpRules(server, registryId, "1.1.1.1", "10.0.0.2"),
+			},
+		},
+		// Destroying the resource leaves the registry untouched.
+		CheckDestroy: testCheckContainerRegistryIpRules(server, registryId, "1.1.1.1", "10.0.0.2"),
+	})
+}
+
+func testContainerRegistryIpRuleFilterConfig(registryId, ipRules string) string {
+	return fmt.Sprintf(`
+resource "azurermext_container_registry_ip_rule_filter" "test" {
+  container_registry_id = %q
+  ip_rules              = [%s]
+}
+`, registryId, ipRules)
+}
+
+// testCheckContainerRegistryIpRules checks the IP rules of a fake registry, in order, and that its default action
+// was kept.
+func testCheckContainerRegistryIpRules(server *fakearm.Server, registryId string, want ...string) func(*terraform.State) error {
+	return func(*terraform.State) error {
+		registry, ok := server.ContainerRegistry(registryId)
+		if !ok {
+			return fmt.Errorf("Container Registry %s not found", registryId)
+		}
+		if !slices.Equal(registry.IpRules, want) {
+			return fmt.Errorf("Container Registry %s has IP rules %v, want %v", registryId, registry.IpRules, want)
+		}
+		if registry.DefaultAction != "Deny" {
+			return fmt.Errorf("Container Registry %s has default action %s, want Deny", registryId, registry.DefaultAction)
+		}
+		return nil
+	}
+}
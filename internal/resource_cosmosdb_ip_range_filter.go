@@ -2,33 +2,88 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"terraform-provider-azurermext/internal/additive"
+	"terraform-provider-azurermext/internal/cidr"
 	"terraform-provider-azurermext/internal/client"
+	"terraform-provider-azurermext/internal/resourceid"
+	"terraform-provider-azurermext/internal/rulemetadata"
+	"time"
 
-	"slices"
-
-	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 var (
-	_ resource.ResourceWithConfigure = (*CosmosDBIpFilterResource)(nil)
+	_ resource.ResourceWithConfigure  = (*CosmosDBIpFilterResource)(nil)
+	_ resource.ResourceWithModifyPlan = (*CosmosDBIpFilterResource)(nil)
+)
+
+const cosmosDBAccountResourceType = "Microsoft.DocumentDB/databaseAccounts"
+
+// Values of unmanaged_rule_policy.
+const (
+	unmanagedRulePolicyIgnore = "ignore"
+	unmanagedRulePolicyWarn   = "warn"
+	unmanagedRulePolicyDeny   = "deny"
 )
 
+var unmanagedRulePolicies = []string{unmanagedRulePolicyIgnore, unmanagedRulePolicyWarn, unmanagedRulePolicyDeny}
+
+// Values of mode.
+const (
+	// ipRulesModeAdditive only manages the configured rules, see package additive.
+	ipRulesModeAdditive = "additive"
+	// ipRulesModeAuthoritative makes the account firewall exactly the configured rules.
+	ipRulesModeAuthoritative = "authoritative"
+)
+
+var ipRulesModes = []string{ipRulesModeAdditive, ipRulesModeAuthoritative}
+
+// cosmosDBMaxIpRules is the maximum number of IP rules of a CosmosDB account.
+const cosmosDBMaxIpRules = 1000
+
+// cosmosDBRequiredActions are the actions the resource needs on the account: writing its IP rules and recording the
+// metadata of the rules in its tags.
+var cosmosDBRequiredActions = []string{
+	"Microsoft.DocumentDB/databaseAccounts/read",
+	"Microsoft.DocumentDB/databaseAccounts/write",
+	"Microsoft.Resources/tags/write",
+}
+
 type CosmosDBIpFilterResource struct {
 	client *client.Client
 }
 
 type CosmosDBMongoDBIpFilterResourceModel struct {
-	ID                types.String `tfsdk:"id"`
-	CosmosDBAccountId types.String `tfsdk:"cosmosdb_account_id"`
-	IpRules           types.List   `tfsdk:"ip_rules"`
+	ID                  types.String `tfsdk:"id"`
+	CosmosDBAccountId   types.String `tfsdk:"cosmosdb_account_id"`
+	IpRules             types.List   `tfsdk:"ip_rules"`
+	EffectiveIpRules    types.List   `tfsdk:"effective_ip_rules"`
+	PendingAdditions    types.List   `tfsdk:"pending_additions"`
+	PendingRemovals     types.List   `tfsdk:"pending_removals"`
+	AllIpRules          types.List   `tfsdk:"all_ip_rules"`
+	UnmanagedIpRules    types.List   `tfsdk:"unmanaged_ip_rules"`
+	UnmanagedRulePolicy types.String `tfsdk:"unmanaged_rule_policy"`
+	Mode                types.String `tfsdk:"mode"`
+	ServiceTags         types.List   `tfsdk:"service_tags"`
+	ServiceTagsFile     types.String `tfsdk:"service_tags_file"`
+	ServiceTagIpRules   types.List   `tfsdk:"service_tag_ip_rules"`
+	Aggregate           types.Bool   `tfsdk:"aggregate"`
+	ManagedIpRules      types.List   `tfsdk:"managed_ip_rules"`
+	MetadataTag         types.String `tfsdk:"metadata_tag"`
 }
 
 func NewCosmosDBMongoDBIpFilterResource() resource.Resource {
@@ -49,7 +104,7 @@ func (r *CosmosDBIpFilterResource) Configure(_ context.Context, req resource.Con
 func (r *CosmosDBIpFilterResource) Schema(_ context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: cosmosDbIpRangeFilterDescription,
-		Version:     1,
+		Version:     2,
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
 				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
@@ -57,18 +112,243 @@ func (r *CosmosDBIpFilterResource) Schema(_ context.Context, req resource.Schema
 			},
 			"cosmosdb_account_id": schema.StringAttribute{
 				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+				Validators:    []validator.String{resourceIdValidator{cosmosDBAccountResourceType}},
 				Required:      true,
 				Description:   "Resource ID of the Azure CosmosDB Account.",
 			},
-			"ip_rules": schema.ListAttribute{
-				ElementType: types.StringType,
+			"ip_rules": schema.ListNestedAttribute{
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"ip": schema.StringAttribute{
+							Validators:  []validator.String{ipRangeValidator{}},
+							Required:    true,
+							Description: "IP address or CIDR range to allow.",
+						},
+						"expires_at": schema.StringAttribute{
+							Validators:  []validator.String{rfc3339Validator{}},
+							Optional:    true,
+							Description: "RFC 3339 timestamp, e.g. `2025-01-31T18:00:00Z`, after which the rule is removed from the account by the next apply. Rules expiring within 7 days are reported as warnings.",
+						},
+						"description": schema.StringAttribute{
+							Optional:    true,
+							Description: "Why the rule exists, recorded in the `metadata_tag` tag of the account.",
+						},
+						"owner": schema.StringAttribute{
+							Optional:    true,
+							Description: "Team or person responsible for the rule, recorded in the `metadata_tag` tag of the account.",
+						},
+					},
+				},
 				Required:    true,
 				Description: "List of IP addresses or CIDR ranges to allow access to the Azure CosmosDB Account.",
 			},
+			"effective_ip_rules": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "Every IP rule of the CosmosDB account, managed by this resource or not, as read from Azure or as it will be once the plan is applied.",
+			},
+			"pending_additions": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "IP rules the last planned change adds to the CosmosDB account. Empty when they all exist already.",
+			},
+			"pending_removals": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "IP rules the last planned change removes from the CosmosDB account: the previously managed ones, or in `authoritative` mode every rule missing from `ip_rules`.",
+			},
+			"all_ip_rules": schema.ListAttribute{
+				PlanModifiers: []planmodifier.List{listplanmodifier.UseStateForUnknown()},
+				ElementType:   types.StringType,
+				Computed:      true,
+				Description:   "Every IP rule of the CosmosDB account as of the last refresh.",
+			},
+			"unmanaged_ip_rules": schema.ListAttribute{
+				PlanModifiers: []planmodifier.List{listplanmodifier.UseStateForUnknown()},
+				ElementType:   types.StringType,
+				Computed:      true,
+				Description:   "IP rules of the CosmosDB account not managed by this resource as of the last refresh.",
+			},
+			"unmanaged_rule_policy": schema.StringAttribute{
+				Validators:  []validator.String{oneOfValidator{unmanagedRulePolicies}},
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(unmanagedRulePolicyIgnore),
+				Description: "What to do when the CosmosDB account has IP rules not managed by this resource: `ignore` them, `warn` about them or `deny` them by failing the plan. In the `additive` mode the rules are never removed; in the `authoritative` mode the next apply removes them, so `deny` only warns. Defaults to `ignore`.",
+			},
+			"mode": schema.StringAttribute{
+				Validators:  []validator.String{oneOfValidator{ipRulesModes}},
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(ipRulesModeAdditive),
+				Description: "`additive` only adds and removes the IP rules of `ip_rules`, keeping the rules added outside of Terraform. `authoritative` makes the account firewall exactly `ip_rules`: any other rule is reported as drift and removed. Defaults to `additive`.",
+			},
+			"service_tags": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Azure service tags, e.g. `AzureCloud.westeurope` or `DataFactory`, whose IPv4 ranges are allowed besides `ip_rules`. The ranges are merged into as few IP rules as possible and follow the updates Microsoft publishes: a changed range shows in the next plan.",
+			},
+			"service_tags_file": schema.StringAttribute{
+				Optional:    true,
+				Description: "Path to a ServiceTags_Public JSON file, as downloaded from the Microsoft Download Center, to read `service_tags` from. When unset they are read from the serviceTags API of the CosmosDB account's subscription.",
+			},
+			"service_tag_ip_rules": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "IP rules of the CosmosDB account managed through `service_tags`.",
+			},
+			"aggregate": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+				Description: "Whether to merge the active `ip_rules` and the ranges of `service_tags` into the fewest CIDR ranges covering exactly the same addresses before writing them, e.g. `10.0.0.0/25` and `10.0.0.128/25` become `10.0.0.0/24`. Keeps long lists under the limit of 1000 IP rules per account. Defaults to `false`.",
+			},
+			"managed_ip_rules": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "IP rules written to the CosmosDB account by this resource: the active `ip_rules` and `service_tag_ip_rules`, merged when `aggregate` is set.",
+			},
+			"metadata_tag": schema.StringAttribute{
+				Validators:  []validator.String{tagNameValidator{}},
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(defaultIpRuleMetadataTag),
+				Description: "Name of the account tag the `description` and `owner` of the IP rules are recorded in, as compressed JSON shared with the other resources managing the account's rules. Longer metadata is split over the tags `{metadata_tag}.1`, `{metadata_tag}.2`... up to 8 tags. Defaults to `" + defaultIpRuleMetadataTag + "`.",
+			},
 		},
 	}
 }
 
+// ModifyPlan reads the live account to preview the PATCH the apply will send: the computed attributes describe it,
+// and warnings tell whether the apply triggers a lengthy account update or only records the rules in the state.
+func (r *CosmosDBIpFilterResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if shouldCheckPermissions(r.client, req) {
+		checkPermissions(ctx, r.client, req.Plan, "cosmosdb_account_id", cosmosDBAccountResourceType, cosmosDBRequiredActions, &resp.Diagnostics)
+	}
+	if r.client == nil || req.Plan.Raw.IsNull() {
+		return
+	}
+	planChanged := !req.Plan.Raw.Equal(req.State.Raw)
+
+	var plan CosmosDBMongoDBIpFilterResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	// An unchanged configuration still needs an update when Microsoft changed the ranges of its service tags.
+	if !planChanged && len(plan.ServiceTags.Elements()) == 0 {
+		return
+	}
+	if plan.CosmosDBAccountId.IsUnknown() || plan.IpRules.IsUnknown() || !allKnown(plan.IpRules) ||
+		plan.ServiceTags.IsUnknown() || !allKnown(plan.ServiceTags) || plan.ServiceTagsFile.IsUnknown() || plan.MetadataTag.IsUnknown() {
+		return
+	}
+	planIpRules := ipRulesFromList(ctx, plan.IpRules, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() || !ipRulesKnown(planIpRules) {
+		return
+	}
+	var state *CosmosDBMongoDBIpFilterResourceModel
+	if !req.State.Raw.IsNull() {
+		state = &CosmosDBMongoDBIpFilterResourceModel{}
+		resp.Diagnostics.Append(req.State.Get(ctx, state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	cosmosID := plan.CosmosDBAccountId.ValueString()
+	adapter := newCosmosDBIpRuleAdapter(r.client, cosmosID)
+	currentIpRules, err := adapter.Read(ctx)
+	if err != nil {
+		// The account may be created by the same apply, its rules are only known then.
+		tflog.Debug(ctx, "Could not preview the IP rules change: "+err.Error())
+		return
+	}
+	serviceTagIpRules, err := r.resolveServiceTags(ctx, &plan, adapter)
+	if err != nil {
+		addClientError(
+			&resp.Diagnostics,
+			"Could not resolve service tags",
+			"Failed to read the IP ranges of the service tags of CosmosDB account "+cosmosID,
+			err,
+		)
+		return
+	}
+	plan.ServiceTagIpRules = stringsToList(ctx, serviceTagIpRules, &resp.Diagnostics)
+	if !planChanged && state.ServiceTagIpRules.Equal(plan.ServiceTagIpRules) {
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("service_tag_ip_rules"), plan.ServiceTagIpRules)...)
+
+	_, previousMetadataIps := previousIpRuleMetadata(ctx, state, &resp.Diagnostics)
+	if _, _, err := ipRuleMetadataTagsChange(plan.MetadataTag.ValueString(), adapter.tags, previousMetadataIps, ipRuleMetadata(planIpRules)); err != nil {
+		addIpRuleMetadataError(cosmosID, err, &resp.Diagnostics)
+		return
+	}
+
+	now := time.Now()
+	if expired := expiredIpRuleIps(planIpRules, now); len(expired) != 0 {
+		resp.Diagnostics.AddAttributeWarning(path.Root("ip_rules"),
+			"Expired IP rules",
+			"The following IP rules have expired and won't be allowed on CosmosDB account "+cosmosID+
+				", they can be removed from the configuration: "+strings.Join(expired, ", "))
+	}
+	if !adapter.publicNetworkAccess() {
+		resp.Diagnostics.AddAttributeWarning(path.Root("cosmosdb_account_id"),
+			"CosmosDB account is not publicly accessible",
+			"CosmosDB account "+cosmosID+" is not publicly accessible, the apply will fail unless public network access is enabled first.")
+		return
+	}
+
+	desired, err := desiredIpRules(planIpRules, serviceTagIpRules, plan.Aggregate.ValueBool(), now)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("ip_rules"), "Invalid IP rules", "The IP rules can't be aggregated: "+err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("managed_ip_rules"), desired)...)
+	var previous []string
+	if state != nil {
+		previous = writtenIpRules(ctx, state, &resp.Diagnostics)
+	}
+	merge, ok := mergeIpRules(adapter, plan.Mode.ValueString(), currentIpRules, previous, desired)
+	if !ok {
+		resp.Diagnostics.AddAttributeWarning(path.Root("ip_rules"),
+			"CosmosDB account is open to all networks",
+			"CosmosDB account "+cosmosID+" has no IP rule, so it accepts every network. The IP rules won't be added since they would block every other network. "+
+				"Use the authoritative mode to restrict the account to the IP rules.")
+	} else {
+		checkIpRulesLimit(cosmosID, merge.Final, desired, plan.Aggregate.ValueBool(), &resp.Diagnostics)
+		if len(merge.Final) == 0 && len(currentIpRules) != 0 {
+			resp.Diagnostics.AddAttributeWarning(path.Root("ip_rules"),
+				"CosmosDB account will be open to all networks",
+				"Applying will remove every IP rule of CosmosDB account "+cosmosID+", which then accepts every network.")
+		}
+		if merge.Changed() {
+			resp.Diagnostics.AddWarning("CosmosDB account update",
+				fmt.Sprintf("Applying will update the firewall of CosmosDB account %s.\n\nAdded IP rules: %s\nRemoved IP rules: %s\n\n"+
+					"CosmosDB account updates usually take 10 to 15 minutes, during which no other update of the account can run.",
+					cosmosID, joinOrNone(merge.Added), joinOrNone(merge.Removed)))
+		} else if state != nil && !state.IpRules.Equal(plan.IpRules) {
+			resp.Diagnostics.AddWarning("No CosmosDB account update",
+				"The firewall of CosmosDB account "+cosmosID+" already matches ip_rules. Applying won't update the account, only the Terraform state.")
+		}
+	}
+
+	if state == nil {
+		// Existing resources are checked by Read.
+		unmanagedIpRules := additive.Unmanaged[string](adapter, desired, currentIpRules)
+		checkUnmanagedIpRules(plan.UnmanagedRulePolicy.ValueString(), plan.Mode.ValueString(), cosmosID, unmanagedIpRules, &resp.Diagnostics)
+	}
+
+	for attribute, values := range map[string][]string{
+		"effective_ip_rules": merge.Final,
+		"pending_additions":  merge.Added,
+		"pending_removals":   merge.Removed,
+	} {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(attribute), values)...)
+	}
+}
+
 func (r *CosmosDBIpFilterResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
 	var state CosmosDBMongoDBIpFilterResourceModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
@@ -76,19 +356,26 @@ func (r *CosmosDBIpFilterResource) Read(ctx context.Context, req resource.ReadRe
 		return
 	}
 
-	cosmo, err := r.client.ReadCosmosDB(ctx, state.CosmosDBAccountId.ValueString())
+	adapter := newCosmosDBIpRuleAdapter(r.client, state.CosmosDBAccountId.ValueString())
+	currentIpRules, err := adapter.Read(ctx)
+	var notFound *client.NotFoundError
+	if errors.As(err, &notFound) {
+		resp.Diagnostics.AddWarning("CosmosDB account not found",
+			"CosmosDB account "+state.CosmosDBAccountId.ValueString()+" was deleted outside of Terraform, it's removed from the state.")
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
-		// TODO: Add not found removing state as opposed to being an error of read
-		//       resp.State.RemoveResource(ctx)
-		resp.Diagnostics.AddError(
+		addClientError(
+			&resp.Diagnostics,
 			"Could not read CosmosDB",
-			"Failed to read CosmosDB account with ID "+state.CosmosDBAccountId.ValueString()+": "+err.Error(),
+			"Failed to read CosmosDB account with ID "+state.CosmosDBAccountId.ValueString(),
+			err,
 		)
 		return
 	}
-	currentIpRules := parseCurrentIpRulesFromResponse(cosmo)
 
-	if !cosmo.Properties.PublicNetworkAccess.IsEnabled() {
+	if !adapter.publicNetworkAccess() {
 		resp.Diagnostics.AddError(
 			"CosmosDB account is not publicly accessible",
 			"CosmosDB account "+state.CosmosDBAccountId.ValueString()+" is not publicly accessible. Please enable public network access to add IP rules.",
@@ -96,25 +383,54 @@ func (r *CosmosDBIpFilterResource) Read(ctx context.Context, req resource.ReadRe
 		return
 	}
 
-	if len(currentIpRules) == 0 {
-		// In this case the CosmosDB account is public
-		return
+	// Null when set by a version of the provider predating the attributes.
+	if state.UnmanagedRulePolicy.IsNull() {
+		state.UnmanagedRulePolicy = types.StringValue(unmanagedRulePolicyIgnore)
 	}
-
-	newStateIpRules := []attr.Value{}
-	for _, stateIPT := range state.IpRules.Elements() {
-		stateIP := stateIPT.(types.String).ValueString()
-		if slices.Contains(currentIpRules, stateIP) {
-			newStateIpRules = append(newStateIpRules, stateIPT)
-		}
+	if state.Mode.IsNull() {
+		state.Mode = types.StringValue(ipRulesModeAdditive)
+	}
+	managedIpRules := ipRulesFromList(ctx, state.IpRules, &resp.Diagnostics)
+	serviceTagIpRules := listToStrings(state.ServiceTagIpRules)
+	now := time.Now()
+	written := writtenIpRules(ctx, &state, &resp.Diagnostics)
+	unmanagedIpRules := additive.Unmanaged[string](adapter, written, currentIpRules)
+	checkUnmanagedIpRules(state.UnmanagedRulePolicy.ValueString(), state.Mode.ValueString(), state.CosmosDBAccountId.ValueString(), unmanagedIpRules, &resp.Diagnostics)
+	for _, rule := range expiringIpRules(managedIpRules, now) {
+		resp.Diagnostics.AddAttributeWarning(path.Root("ip_rules"),
+			"IP rule expires soon",
+			"IP rule "+rule.Ip.ValueString()+" of CosmosDB account "+state.CosmosDBAccountId.ValueString()+" expires at "+rule.ExpiresAt.ValueString()+
+				", it will be removed by the first apply after that. Update its expires_at to extend it.")
+	}
+	state.AllIpRules = stringsToList(ctx, currentIpRules, &resp.Diagnostics)
+	state.EffectiveIpRules = state.AllIpRules
+	state.UnmanagedIpRules = stringsToList(ctx, unmanagedIpRules, &resp.Diagnostics)
+	// Every rule is reported in authoritative mode, so that any difference with the configuration shows as drift.
+	// Otherwise an empty list means the CosmosDB account is public and the managed rules were never added, they are
+	// kept as is.
+	if authoritative := state.Mode.ValueString() == ipRulesModeAuthoritative; authoritative || len(currentIpRules) != 0 {
+		liveWritten := additive.Filter[string](adapter, written, currentIpRules)
+		view := ipRulesView(managedIpRules, written, currentIpRules, now)
+		state.IpRules = ipRulesToList(ctx, refreshIpRules(managedIpRules, view, authoritative, now), &resp.Diagnostics)
+		state.ServiceTagIpRules = stringsToList(ctx, coveredIpRules(serviceTagIpRules, liveWritten), &resp.Diagnostics)
+		state.ManagedIpRules = stringsToList(ctx, liveWritten, &resp.Diagnostics)
+		state.ID = types.StringValue(adapter.resourceID())
 	}
-	newIpRulesState, diags := types.ListValue(types.StringType, newStateIpRules)
-	resp.Diagnostics.Append(diags...)
-	if diags.HasError() {
+	if state.MetadataTag.IsNull() {
+		state.MetadataTag = types.StringValue(defaultIpRuleMetadataTag)
+	}
+	metadata, err := rulemetadata.Decode(state.MetadataTag.ValueString(), adapter.tags)
+	if err != nil {
+		resp.Diagnostics.AddAttributeWarning(path.Root("metadata_tag"),
+			"Invalid IP rule metadata",
+			"The description and owner of the IP rules of CosmosDB account "+state.CosmosDBAccountId.ValueString()+" can't be read: "+err.Error()+
+				". The tag was likely edited outside of Terraform, the next apply rewrites it.")
+		metadata = map[string]rulemetadata.Metadata{}
+	}
+	state.IpRules = ipRulesToList(ctx, refreshIpRuleMetadata(ipRulesFromList(ctx, state.IpRules, &resp.Diagnostics), metadata), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
 		return
 	}
-	state.IpRules = newIpRulesState
-	state.ID = types.StringValue(cosmo.ID)
 	resp.State.Set(ctx, &state)
 }
 
@@ -124,7 +440,7 @@ func (r *CosmosDBIpFilterResource) Create(ctx context.Context, req resource.Crea
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	r.upsertCosmosDB(ctx, nil, &plan, resp.Diagnostics)
+	r.upsertCosmosDB(ctx, nil, &plan, &resp.Diagnostics)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -142,7 +458,7 @@ func (r *CosmosDBIpFilterResource) Update(ctx context.Context, req resource.Upda
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	r.upsertCosmosDB(ctx, &state, &plan, resp.Diagnostics)
+	r.upsertCosmosDB(ctx, &state, &plan, &resp.Diagnostics)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -163,19 +479,22 @@ func (r *CosmosDBIpFilterResource) Delete(ctx context.Context, req resource.Dele
 }
 
 // This method modifies state and diags inplace
-func (r *CosmosDBIpFilterResource) upsertCosmosDB(ctx context.Context, state, plan *CosmosDBMongoDBIpFilterResourceModel, diags diag.Diagnostics) {
+func (r *CosmosDBIpFilterResource) upsertCosmosDB(ctx context.Context, state, plan *CosmosDBMongoDBIpFilterResourceModel, diags *diag.Diagnostics) {
 	cosmosID := plan.CosmosDBAccountId.ValueString()
-	cosmo, err := r.client.ReadCosmosDB(ctx, cosmosID)
+	adapter := newCosmosDBIpRuleAdapter(r.client, cosmosID)
+	currentIpRules, err := adapter.Read(ctx)
 	if err != nil {
-		diags.AddError(
+		addClientError(
+			diags,
 			"Could not read CosmosDB",
-			"Failed to read CosmosDB account with ID "+cosmosID+": "+err.Error(),
+			"Failed to read CosmosDB account with ID "+cosmosID,
+			err,
 		)
 		return
 	}
-	plan.ID = types.StringValue(cosmo.ID)
+	plan.ID = types.StringValue(adapter.resourceID())
 
-	if !cosmo.Properties.PublicNetworkAccess.IsEnabled() {
+	if !adapter.publicNetworkAccess() {
 		diags.AddError(
 			"CosmosDB account is not publicly accessible",
 			"CosmosDB account "+cosmosID+" is not publicly accessible. Please enable public network access to add IP rules.",
@@ -183,65 +502,217 @@ func (r *CosmosDBIpFilterResource) upsertCosmosDB(ctx context.Context, state, pl
 		return
 	}
 
-	currentIpRules := parseCurrentIpRulesFromResponse(cosmo)
-	if len(currentIpRules) == 0 {
-		// In this case the CosmosDB account is public, so we avoid adding any IP rules otherwise we would block access.
-		// Technically speaking we should check that there are no approved private endpoints as well, but I'd rather err on the side of caution here.
-		// Attempting to add ip rules when public removes the 'publicness' of the account.
-		plan.ID = types.StringValue(cosmo.ID)
-		return
-	}
-
-	// figuring out which rules to remove
-	rulesToRemove := []string{}
+	var previous []string
 	if state != nil {
-		for _, stateIPT := range state.IpRules.Elements() {
-			stateIP := stateIPT.(types.String).ValueString()
-			foundInPlan := false
-			for _, planIPT := range plan.IpRules.Elements() {
-				planIP := planIPT.(types.String).ValueString()
-				if stateIP == planIP {
-					foundInPlan = true
-					break
-				}
-			}
-			if !foundInPlan {
-				rulesToRemove = append(rulesToRemove, stateIP)
-			}
-		}
+		previous = writtenIpRules(ctx, state, diags)
 	}
-	// figuring out which rules to add
-	newRules := []string{}
-	for _, planIPT := range plan.IpRules.Elements() {
-		planIP := planIPT.(types.String).ValueString()
-		if !slices.Contains(currentIpRules, planIP) {
-			newRules = append(newRules, planIP)
+	if plan.ServiceTagIpRules.IsUnknown() {
+		serviceTagIpRules, err := r.resolveServiceTags(ctx, plan, adapter)
+		if err != nil {
+			addClientError(
+				diags,
+				"Could not resolve service tags",
+				"Failed to read the IP ranges of the service tags of CosmosDB account "+cosmosID,
+				err,
+			)
+			return
 		}
+		plan.ServiceTagIpRules = stringsToList(ctx, serviceTagIpRules, diags)
 	}
-
-	// finalizing the IP rules to be set
-	finalIPRules := []string{}
-	for _, rule := range cosmo.Properties.IpRules {
-		ip := rule.IpAddressOrRange
-		if ip != "" && !slices.Contains(rulesToRemove, ip) {
-			finalIPRules = append(finalIPRules, ip)
+	// The rules previewed at plan time are written even if some expired since, the next plan removes them.
+	if plan.ManagedIpRules.IsUnknown() {
+		desired, err := desiredIpRules(ipRulesFromList(ctx, plan.IpRules, diags), listToStrings(plan.ServiceTagIpRules), plan.Aggregate.ValueBool(), time.Now())
+		if err != nil {
+			diags.AddAttributeError(path.Root("ip_rules"), "Invalid IP rules", "The IP rules can't be aggregated: "+err.Error())
+			return
 		}
+		plan.ManagedIpRules = stringsToList(ctx, desired, diags)
 	}
-	finalIPRules = append(finalIPRules, newRules...)
+	desired := listToStrings(plan.ManagedIpRules)
+	merge, _ := mergeIpRules(adapter, plan.Mode.ValueString(), currentIpRules, previous, desired)
+	setPlannedIpRulesChange(ctx, plan, merge, diags)
 
-	if len(newRules) != 0 || len(rulesToRemove) != 0 {
-		tflog.Info(ctx, fmt.Sprintf("IP Rules to add: %v", newRules))
-		tflog.Info(ctx, fmt.Sprintf("IP Rules to remove: %v", rulesToRemove))
-		err = r.client.UpdateCosmosDBIpRulesAndPoll(ctx, plan.CosmosDBAccountId.ValueString(), finalIPRules)
+	if merge.Changed() {
+		tflog.Info(ctx, fmt.Sprintf("IP Rules to add: %v", merge.Added))
+		tflog.Info(ctx, fmt.Sprintf("IP Rules to remove: %v", merge.Removed))
+		err = commitIpRules(ctx, r.client, adapter, merge)
 		tflog.Info(ctx, "Finished updating IP Rules")
 		if err != nil {
-			diags.AddError(
+			addClientError(
+				diags,
 				"Could not update CosmosDB IP rules",
-				err.Error(),
+				"Failed to update the IP rules of CosmosDB account "+cosmosID,
+				err,
 			)
 			return
 		}
 	}
+
+	// The metadata is recorded even when the rules aren't written because the account is open to all networks, it
+	// doesn't change the firewall.
+	r.writeIpRuleMetadata(ctx, adapter, state, plan, diags)
+}
+
+// commitIpRules writes the merged rules once the client allows another update, see client.WithMaxConcurrentUpdates.
+func commitIpRules(ctx context.Context, c *client.Client, adapter *cosmosDBIpRuleAdapter, merge additive.Result[string]) error {
+	release, err := c.AcquireUpdateSlot(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return additive.Commit[string](ctx, adapter, merge)
+}
+
+// mergeIpRules computes the IP rules to write according to mode. ok is false when the rules are left untouched
+// because the account is open to all networks.
+func mergeIpRules(adapter *cosmosDBIpRuleAdapter, mode string, current, previous, desired []string) (_ additive.Result[string], ok bool) {
+	if mode == ipRulesModeAuthoritative {
+		return additive.Replace[string](adapter, current, desired), true
+	}
+	if len(current) == 0 {
+		// In this case the CosmosDB account is public, so we avoid adding any IP rules otherwise we would block access.
+		// Technically speaking we should check that there are no approved private endpoints as well, but I'd rather err on the side of caution here.
+		// Attempting to add ip rules when public removes the 'publicness' of the account.
+		return additive.Result[string]{Final: current, Added: []string{}, Updated: []string{}, Removed: []string{}}, false
+	}
+	return additive.Merge[string](adapter, current, previous, desired), true
+}
+
+func joinOrNone(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	return strings.Join(values, ", ")
+}
+
+// setPlannedIpRulesChange fills the computed attributes ModifyPlan couldn't preview, e.g. because the account
+// didn't exist yet. Values previewed at plan time are kept as Terraform requires the result to match the plan.
+func setPlannedIpRulesChange(ctx context.Context, plan *CosmosDBMongoDBIpFilterResourceModel, merge additive.Result[string], diags *diag.Diagnostics) {
+	for _, attribute := range []struct {
+		value  *types.List
+		values []string
+	}{
+		{&plan.EffectiveIpRules, merge.Final},
+		{&plan.PendingAdditions, merge.Added},
+		{&plan.PendingRemovals, merge.Removed},
+		{&plan.AllIpRules, merge.Final},
+		{&plan.UnmanagedIpRules, additive.Unmanaged[string](additive.Strings{}, listToStrings(plan.ManagedIpRules), merge.Final)},
+	} {
+		if attribute.value.IsUnknown() {
+			*attribute.value = stringsToList(ctx, attribute.values, diags)
+		}
+	}
+}
+
+// desiredIpRules returns the IP rules to write: the active ip_rules and the service tag rules, merged into the fewest
+// ranges when aggregate is set.
+func desiredIpRules(ipRules []ipRuleModel, serviceTagIpRules []string, aggregate bool, now time.Time) ([]string, error) {
+	desired := append(activeIpRuleIps(ipRules, now), serviceTagIpRules...)
+	if !aggregate {
+		return desired, nil
+	}
+	return cidr.Merge(desired)
+}
+
+// writtenIpRules returns the IP rules the resource wrote to the account. States predating managed_ip_rules were
+// never aggregated, their rules are the ones of ip_rules and service_tag_ip_rules.
+func writtenIpRules(ctx context.Context, model *CosmosDBMongoDBIpFilterResourceModel, diags *diag.Diagnostics) []string {
+	if !model.ManagedIpRules.IsNull() && !model.ManagedIpRules.IsUnknown() {
+		return listToStrings(model.ManagedIpRules)
+	}
+	return append(ipRuleIps(ipRulesFromList(ctx, model.IpRules, diags)), listToStrings(model.ServiceTagIpRules)...)
+}
+
+// checkIpRulesLimit fails the plan when the account would end up with more IP rules than CosmosDB accepts, instead
+// of letting the apply fail after the account update started.
+func checkIpRulesLimit(cosmosID string, final, desired []string, aggregate bool, diags *diag.Diagnostics) {
+	if len(final) <= cosmosDBMaxIpRules {
+		return
+	}
+	unmanaged := additive.Unmanaged[string](additive.Strings{}, desired, final)
+	overflow := final[cosmosDBMaxIpRules:]
+	detail := fmt.Sprintf("CosmosDB account %s would have %d IP rules, %d managed by this resource and %d unmanaged, "+
+		"but CosmosDB accepts at most %d. The following %d IP rules don't fit:\n\n  - %s",
+		cosmosID, len(final), len(final)-len(unmanaged), len(unmanaged), cosmosDBMaxIpRules, len(overflow), strings.Join(overflow, "\n  - "))
+	if merged, err := cidr.Merge(desired); !aggregate && err == nil && len(merged) < len(desired) {
+		detail += fmt.Sprintf("\n\nSetting aggregate to true would merge the %d managed IP rules into %d.", len(desired), len(merged))
+	}
+	diags.AddAttributeError(path.Root("ip_rules"), "Too many IP rules", detail)
+}
+
+// resolveServiceTags returns the IP rules of service_tags, read from service_tags_file or from the serviceTags API
+// of the account's subscription. The adapter must have read the account.
+func (r *CosmosDBIpFilterResource) resolveServiceTags(ctx context.Context, model *CosmosDBMongoDBIpFilterResourceModel, adapter *cosmosDBIpRuleAdapter) ([]string, error) {
+	names := listToStrings(model.ServiceTags)
+	if len(names) == 0 {
+		return []string{}, nil
+	}
+	tags, err := readServiceTags(ctx, r.client, model.ServiceTagsFile.ValueString(), adapter.parsedId.SubscriptionID, adapter.location)
+	if err != nil {
+		return nil, err
+	}
+	return serviceTagIpRules(tags, names)
+}
+
+// checkUnmanagedIpRules applies unmanaged_rule_policy to the IP rules of an account not managed by the resource.
+// In authoritative mode the next apply removes them, so deny only warns: failing would block the apply fixing it.
+func checkUnmanagedIpRules(policy, mode, cosmosID string, unmanaged []string, diags *diag.Diagnostics) {
+	if len(unmanaged) == 0 {
+		return
+	}
+	detail := "CosmosDB account " + cosmosID + " has IP rules not managed by Terraform: " + strings.Join(unmanaged, ", ") + "."
+	if mode == ipRulesModeAuthoritative {
+		if policy != unmanagedRulePolicyIgnore {
+			diags.AddAttributeWarning(path.Root("unmanaged_ip_rules"), "Unmanaged CosmosDB IP rules",
+				detail+" The next apply removes them, mode is set to authoritative.")
+		}
+		return
+	}
+	switch policy {
+	case unmanagedRulePolicyWarn:
+		diags.AddAttributeWarning(path.Root("unmanaged_ip_rules"), "Unmanaged CosmosDB IP rules", detail)
+	case unmanagedRulePolicyDeny:
+		diags.AddAttributeError(path.Root("unmanaged_ip_rules"), "Unmanaged CosmosDB IP rules",
+			detail+" Remove them from the account or add them to ip_rules, unmanaged_rule_policy is set to deny.")
+	}
+}
+
+// cosmosDBIpRuleAdapter manages the `ipRules` list of a CosmosDB account.
+type cosmosDBIpRuleAdapter struct {
+	ipRuleAdapterBase
+	cosmosAccountId string
+	parsedId        resourceid.ID
+	location        string
+	tags            map[string]string
+}
+
+func newCosmosDBIpRuleAdapter(c *client.Client, cosmosAccountId string) *cosmosDBIpRuleAdapter {
+	return &cosmosDBIpRuleAdapter{ipRuleAdapterBase: ipRuleAdapterBase{client: c}, cosmosAccountId: cosmosAccountId}
+}
+
+func (a *cosmosDBIpRuleAdapter) Read(ctx context.Context) ([]string, error) {
+	parsedId, err := resourceid.ParseAs(a.cosmosAccountId, cosmosDBAccountResourceType)
+	if err != nil {
+		return nil, err
+	}
+	cosmo, err := a.client.ReadCosmosDB(ctx, parsedId)
+	if err != nil {
+		return nil, err
+	}
+	if cosmo.Properties == nil {
+		return nil, fmt.Errorf("CosmosDB account %s has no properties", a.cosmosAccountId)
+	}
+	a.parsedId = parsedId
+	a.id = cosmo.ID
+	a.location = cosmo.Location
+	a.tags = cosmo.Tags
+	a.public = cosmo.Properties.PublicNetworkAccess.IsEnabled()
+	return parseCurrentIpRulesFromResponse(cosmo), nil
+}
+
+func (a *cosmosDBIpRuleAdapter) Write(ctx context.Context, rules []string) (err error) {
+	a.operation, err = a.client.UpdateCosmosDBIpRules(ctx, a.parsedId, rules)
+	return err
 }
 
 func parseCurrentIpRulesFromResponse(cosmo *client.CosmosDBResponse) []string {
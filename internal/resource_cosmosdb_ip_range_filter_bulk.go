@@ -0,0 +1,551 @@
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"terraform-provider-azurermext/internal/additive"
+	"terraform-provider-azurermext/internal/client"
+	"terraform-provider-azurermext/internal/resourceid"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var (
+	_ resource.ResourceWithConfigure  = (*CosmosDBIpFilterBulkResource)(nil)
+	_ resource.ResourceWithModifyPlan = (*CosmosDBIpFilterBulkResource)(nil)
+)
+
+// maxConcurrentAccountReads bounds the accounts read at once. Updates are bounded by the client, see
+// client.WithMaxConcurrentUpdates.
+const maxConcurrentAccountReads = 16
+
+// CosmosDBIpFilterBulkResource applies the same IP rules to many CosmosDB accounts, like as many
+// azurermext_cosmosdb_ip_range_filter in additive mode but with the accounts read and updated in parallel.
+type CosmosDBIpFilterBulkResource struct {
+	client *client.Client
+}
+
+type CosmosDBIpFilterBulkResourceModel struct {
+	ID                 types.String `tfsdk:"id"`
+	CosmosDBAccountIds types.List   `tfsdk:"cosmosdb_account_ids"`
+	Scope              types.String `tfsdk:"scope"`
+	IpRules            types.List   `tfsdk:"ip_rules"`
+	AccountIds         types.List   `tfsdk:"account_ids"`
+	Accounts           types.Map    `tfsdk:"accounts"`
+}
+
+// bulkAccountModel is an entry of the accounts of azurermext_cosmosdb_ip_range_filter_bulk.
+type bulkAccountModel struct {
+	ManagedIpRules   types.List `tfsdk:"managed_ip_rules"`
+	EffectiveIpRules types.List `tfsdk:"effective_ip_rules"`
+	PendingAdditions types.List `tfsdk:"pending_additions"`
+	PendingRemovals  types.List `tfsdk:"pending_removals"`
+}
+
+var bulkAccountObjectType = types.ObjectType{AttrTypes: map[string]attr.Type{
+	"managed_ip_rules":   types.ListType{ElemType: types.StringType},
+	"effective_ip_rules": types.ListType{ElemType: types.StringType},
+	"pending_additions":  types.ListType{ElemType: types.StringType},
+	"pending_removals":   types.ListType{ElemType: types.StringType},
+}}
+
+// bulkAccount is the IP rules change of one account.
+type bulkAccount struct {
+	managed []string
+	merge   additive.Result[string]
+	// unknown is set when the plan couldn't preview the account, its entry is only known after the apply.
+	unknown bool
+}
+
+func NewCosmosDBIpFilterBulkResource() resource.Resource {
+	return &CosmosDBIpFilterBulkResource{}
+}
+
+func (r *CosmosDBIpFilterBulkResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_cosmosdb_ip_range_filter_bulk"
+}
+
+func (r *CosmosDBIpFilterBulkResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*client.Client)
+}
+
+func (r *CosmosDBIpFilterBulkResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	stringList := func(description string) schema.ListAttribute {
+		return schema.ListAttribute{ElementType: types.StringType, Computed: true, Description: description}
+	}
+	resp.Schema = schema.Schema{
+		Description: cosmosDbIpRangeFilterBulkDescription,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+				Computed:      true,
+			},
+			"cosmosdb_account_ids": schema.ListAttribute{
+				ElementType: types.StringType,
+				Validators:  []validator.List{resourceIdValidator{cosmosDBAccountResourceType}},
+				Optional:    true,
+				Description: "Resource IDs of the Azure CosmosDB Accounts. The rules written by this resource are removed from an account leaving the list, unless that would open it to all networks.",
+			},
+			"scope": schema.StringAttribute{
+				Validators:  []validator.String{scopeValidator{}},
+				Optional:    true,
+				Description: "ID of a subscription or resource group, e.g. `/subscriptions/{id}/resourceGroups/{name}`, whose CosmosDB accounts all get `ip_rules`, besides `cosmosdb_account_ids`. The accounts are listed again on every plan, so new accounts get the rules by the next apply, and the rules are removed from the accounts leaving it.",
+			},
+			"ip_rules": schema.ListAttribute{
+				ElementType: types.StringType,
+				Validators:  []validator.List{ipRangeValidator{}},
+				Required:    true,
+				Description: "List of IP addresses or CIDR ranges to allow access to every CosmosDB account. Rules added outside of Terraform are kept.",
+			},
+			"account_ids": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "Resource IDs of the CosmosDB accounts the rules apply to: `cosmosdb_account_ids` and the accounts of `scope`, sorted.",
+			},
+			"accounts": schema.MapNestedAttribute{
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"managed_ip_rules":   stringList("IP rules written to the account by this resource. Keeps the previous rules when the last update of the account failed, so that the next apply retries it."),
+						"effective_ip_rules": stringList("Every IP rule of the account, as read from Azure or as it will be once the plan is applied."),
+						"pending_additions":  stringList("IP rules the last planned change adds to the account."),
+						"pending_removals":   stringList("IP rules the last planned change removes from the account."),
+					},
+				},
+				Computed:    true,
+				Description: "IP rules of every account of `account_ids`, by account ID.",
+			},
+		},
+	}
+}
+
+// ModifyPlan reads every account to preview the updates, which also plans an update when the rules of an account
+// drifted or its last update failed.
+func (r *CosmosDBIpFilterBulkResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if r.client == nil || req.Plan.Raw.IsNull() {
+		return
+	}
+	var plan CosmosDBIpFilterBulkResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if plan.CosmosDBAccountIds.IsUnknown() || !allKnown(plan.CosmosDBAccountIds) || plan.Scope.IsUnknown() ||
+		plan.IpRules.IsUnknown() || !allKnown(plan.IpRules) {
+		return
+	}
+	var state *CosmosDBIpFilterBulkResourceModel
+	if !req.State.Raw.IsNull() {
+		state = &CosmosDBIpFilterBulkResourceModel{}
+		resp.Diagnostics.Append(req.State.Get(ctx, state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	accountIds, err := r.resolveAccountIds(ctx, &plan)
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Could not list CosmosDB accounts", "Failed to list the CosmosDB accounts of "+plan.Scope.ValueString(), err)
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("account_ids"), accountIds)...)
+
+	desired := listToStrings(plan.IpRules)
+	previous := previousBulkIpRules(ctx, state, &resp.Diagnostics)
+	adapters, current, errs := readCosmosDBAccounts(ctx, r.client, accountIds)
+	accounts := map[string]bulkAccount{}
+	var changed []string
+	unknown := false
+	for i, id := range accountIds {
+		if errs[i] != nil {
+			// The account may be created by the same apply, its rules are only known then.
+			tflog.Debug(ctx, "Could not preview the IP rules change of "+id+": "+errs[i].Error())
+			accounts[id] = bulkAccount{unknown: true}
+			unknown = true
+			continue
+		}
+		if !adapters[i].publicNetworkAccess() {
+			resp.Diagnostics.AddAttributeWarning(path.Root("account_ids"),
+				"CosmosDB account is not publicly accessible",
+				"CosmosDB account "+id+" is not publicly accessible, the apply will fail unless public network access is enabled first.")
+			accounts[id] = bulkAccount{unknown: true}
+			unknown = true
+			continue
+		}
+		merge, ok := mergeIpRules(adapters[i], ipRulesModeAdditive, current[i], previous[strings.ToLower(id)], desired)
+		if !ok {
+			resp.Diagnostics.AddAttributeWarning(path.Root("ip_rules"),
+				"CosmosDB account is open to all networks",
+				"CosmosDB account "+id+" has no IP rule, so it accepts every network. The IP rules won't be added since they would block every other network.")
+		} else {
+			checkIpRulesLimit(id, merge.Final, desired, false, &resp.Diagnostics)
+		}
+		if merge.Changed() {
+			changed = append(changed, fmt.Sprintf("%s\n    Added IP rules: %s\n    Removed IP rules: %s", id, joinOrNone(merge.Added), joinOrNone(merge.Removed)))
+		}
+		accounts[id] = bulkAccount{managed: desired, merge: merge}
+	}
+	dropped := droppedBulkAccounts(state, accountIds)
+	for _, id := range dropped {
+		if rules := previous[strings.ToLower(id)]; len(rules) != 0 {
+			changed = append(changed, fmt.Sprintf("%s, no longer in the set\n    Removed IP rules, if still present: %s", id, joinOrNone(rules)))
+		}
+	}
+	// Nothing to apply: the state is kept, its pending rules are those of the last change.
+	if state != nil && len(changed) == 0 && !unknown && len(dropped) == 0 && state.IpRules.Equal(plan.IpRules) &&
+		slices.Equal(listToStrings(state.AccountIds), accountIds) {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("accounts"), state.Accounts)...)
+		return
+	}
+	if len(changed) != 0 {
+		resp.Diagnostics.AddWarning("CosmosDB account updates",
+			fmt.Sprintf("Applying will update the firewall of %d CosmosDB accounts:\n\n  - %s\n\n"+
+				"CosmosDB account updates usually take 10 to 15 minutes, up to max_concurrent_updates of the provider run at once.",
+				len(changed), strings.Join(changed, "\n  - ")))
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("accounts"), bulkAccountsToMap(ctx, accounts, &resp.Diagnostics))...)
+}
+
+func (r *CosmosDBIpFilterBulkResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state CosmosDBIpFilterBulkResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	accountIds := listToStrings(state.AccountIds)
+	previous := previousBulkIpRules(ctx, &state, &resp.Diagnostics)
+	// The accounts dropped from the set whose rules couldn't be removed yet are read too, to keep tracking them.
+	dropped := droppedBulkAccounts(&state, accountIds)
+	_, current, errs := readCosmosDBAccounts(ctx, r.client, append(slices.Clone(accountIds), dropped...))
+	remaining := []string{}
+	accounts := map[string]bulkAccount{}
+	for i, id := range append(slices.Clone(accountIds), dropped...) {
+		var notFound *client.NotFoundError
+		switch {
+		case errors.As(errs[i], &notFound):
+			if i < len(accountIds) {
+				resp.Diagnostics.AddAttributeWarning(path.Root("account_ids"),
+					"CosmosDB account not found",
+					"CosmosDB account "+id+" was deleted outside of Terraform, it's removed from the state.")
+			}
+			continue
+		case errs[i] != nil:
+			addClientError(&resp.Diagnostics, "Could not read CosmosDB", "Failed to read CosmosDB account with ID "+id, errs[i])
+			continue
+		}
+		if i < len(accountIds) {
+			remaining = append(remaining, id)
+		}
+		// Any difference between the managed rules and the account is planned by ModifyPlan.
+		accounts[id] = bulkAccount{
+			managed: previous[strings.ToLower(id)],
+			merge:   additive.Result[string]{Final: current[i], Added: []string{}, Updated: []string{}, Removed: []string{}},
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	state.AccountIds = stringsToList(ctx, remaining, &resp.Diagnostics)
+	state.Accounts = bulkAccountsToMap(ctx, accounts, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.State.Set(ctx, &state)
+}
+
+func (r *CosmosDBIpFilterBulkResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan CosmosDBIpFilterBulkResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.upsert(ctx, nil, &plan, &resp.Diagnostics)
+	resp.State.Set(ctx, &plan)
+}
+
+func (r *CosmosDBIpFilterBulkResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan CosmosDBIpFilterBulkResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var state CosmosDBIpFilterBulkResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.upsert(ctx, &state, &plan, &resp.Diagnostics)
+	resp.State.Set(ctx, &plan)
+}
+
+// Delete is a no-op for the same reasons as CosmosDBIpFilterResource.Delete.
+func (r *CosmosDBIpFilterBulkResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
+
+// upsert updates every account in parallel. Failures are reported for each account and the others are still
+// updated. The state is saved even on failure, a failed account keeps its previous managed rules so that the next
+// plan retries it. This method modifies plan and diags inplace.
+func (r *CosmosDBIpFilterBulkResource) upsert(ctx context.Context, state, plan *CosmosDBIpFilterBulkResourceModel, diags *diag.Diagnostics) {
+	if plan.ID.IsUnknown() {
+		plan.ID = types.StringValue(newBulkID())
+	}
+	var accountIds []string
+	if plan.AccountIds.IsUnknown() {
+		var err error
+		if accountIds, err = r.resolveAccountIds(ctx, plan); err != nil {
+			addClientError(diags, "Could not list CosmosDB accounts", "Failed to list the CosmosDB accounts of "+plan.Scope.ValueString(), err)
+			plan.AccountIds = types.ListValueMust(types.StringType, nil)
+			plan.Accounts = types.MapValueMust(bulkAccountObjectType, nil)
+			return
+		}
+		plan.AccountIds = stringsToList(ctx, accountIds, diags)
+	} else {
+		accountIds = listToStrings(plan.AccountIds)
+	}
+	// The accounts the plan couldn't preview are unknown entries.
+	var planned map[string]types.Object
+	if !plan.Accounts.IsUnknown() {
+		diags.Append(plan.Accounts.ElementsAs(ctx, &planned, false)...)
+	}
+
+	desired := listToStrings(plan.IpRules)
+	previous := previousBulkIpRules(ctx, state, diags)
+	adapters, current, errs := readCosmosDBAccounts(ctx, r.client, accountIds)
+	accounts := make([]bulkAccount, len(accountIds))
+	var wg sync.WaitGroup
+	for i, id := range accountIds {
+		accounts[i] = bulkAccount{managed: previous[strings.ToLower(id)], merge: additive.Result[string]{Final: current[i], Added: []string{}, Updated: []string{}, Removed: []string{}}}
+		if errs[i] == nil && !adapters[i].publicNetworkAccess() {
+			errs[i] = fmt.Errorf("CosmosDB account %s is not publicly accessible. Please enable public network access to add IP rules", id)
+		}
+		if errs[i] != nil {
+			continue
+		}
+		merge, _ := mergeIpRules(adapters[i], ipRulesModeAdditive, current[i], previous[strings.ToLower(id)], desired)
+		if !merge.Changed() {
+			accounts[i] = bulkAccount{managed: desired, merge: merge}
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tflog.Info(ctx, fmt.Sprintf("Updating the IP rules of %s, adding %v and removing %v", id, merge.Added, merge.Removed))
+			if errs[i] = commitIpRules(ctx, r.client, adapters[i], merge); errs[i] == nil {
+				accounts[i] = bulkAccount{managed: desired, merge: merge}
+			}
+		}()
+	}
+	wg.Wait()
+
+	models := map[string]bulkAccountModel{}
+	for i, id := range accountIds {
+		if errs[i] != nil {
+			addClientError(diags, "Could not update CosmosDB IP rules", "Failed to update the IP rules of CosmosDB account "+id, errs[i])
+		}
+		model := bulkAccountToModel(ctx, accounts[i], diags)
+		// Values previewed at plan time are kept as Terraform requires the result to match the plan.
+		if object, ok := planned[id]; ok && !object.IsUnknown() && errs[i] == nil {
+			var p bulkAccountModel
+			diags.Append(object.As(ctx, &p, basetypes.ObjectAsOptions{})...)
+			model.EffectiveIpRules, model.PendingAdditions, model.PendingRemovals = p.EffectiveIpRules, p.PendingAdditions, p.PendingRemovals
+		}
+		models[id] = model
+	}
+	for id, account := range r.removeDroppedIpRules(ctx, state, accountIds, diags) {
+		models[id] = bulkAccountToModel(ctx, account, diags)
+	}
+	accountsMap, d := types.MapValueFrom(ctx, bulkAccountObjectType, models)
+	diags.Append(d...)
+	plan.Accounts = accountsMap
+}
+
+// removeDroppedIpRules removes the managed rules of the accounts of state no longer in accountIds, so that an account
+// leaving cosmosdb_account_ids or scope doesn't keep them forever. The rules are kept, with a warning, when removing
+// them would leave the account without any rule and so open to all networks. It returns the accounts whose rules
+// couldn't be removed, which stay in the state so that the next apply retries.
+func (r *CosmosDBIpFilterBulkResource) removeDroppedIpRules(ctx context.Context, state *CosmosDBIpFilterBulkResourceModel, accountIds []string, diags *diag.Diagnostics) map[string]bulkAccount {
+	dropped := droppedBulkAccounts(state, accountIds)
+	previous := previousBulkIpRules(ctx, state, diags)
+	adapters, current, errs := readCosmosDBAccounts(ctx, r.client, dropped)
+	var wg sync.WaitGroup
+	for i, id := range dropped {
+		var notFound *client.NotFoundError
+		if errors.As(errs[i], &notFound) {
+			// Nothing to clean up.
+			errs[i] = nil
+			continue
+		}
+		if errs[i] != nil {
+			continue
+		}
+		merge := additive.Merge[string](adapters[i], current[i], previous[strings.ToLower(id)], []string{})
+		if !merge.Changed() {
+			continue
+		}
+		if len(merge.Final) == 0 {
+			diags.AddAttributeWarning(path.Root("account_ids"),
+				"CosmosDB account IP rules kept",
+				"CosmosDB account "+id+" is no longer in the set, but its IP rules "+strings.Join(merge.Removed, ", ")+
+					" are kept: removing them would leave it without any IP rule, open to all networks.")
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tflog.Info(ctx, fmt.Sprintf("Removing the IP rules %v of %s, no longer in the set", merge.Removed, id))
+			errs[i] = commitIpRules(ctx, r.client, adapters[i], merge)
+		}()
+	}
+	wg.Wait()
+
+	failed := map[string]bulkAccount{}
+	for i, id := range dropped {
+		if errs[i] != nil {
+			addClientError(diags, "Could not update CosmosDB IP rules", "Failed to remove the IP rules of CosmosDB account "+id+", no longer in the set", errs[i])
+			failed[id] = bulkAccount{
+				managed: previous[strings.ToLower(id)],
+				merge:   additive.Result[string]{Final: current[i], Added: []string{}, Updated: []string{}, Removed: []string{}},
+			}
+		}
+	}
+	return failed
+}
+
+// droppedBulkAccounts returns the accounts of state missing from accountIds, sorted.
+func droppedBulkAccounts(state *CosmosDBIpFilterBulkResourceModel, accountIds []string) []string {
+	if state == nil || state.Accounts.IsNull() || state.Accounts.IsUnknown() {
+		return nil
+	}
+	kept := map[string]struct{}{}
+	for _, id := range accountIds {
+		kept[strings.ToLower(id)] = struct{}{}
+	}
+	dropped := []string{}
+	for id := range state.Accounts.Elements() {
+		if _, ok := kept[strings.ToLower(id)]; !ok {
+			dropped = append(dropped, id)
+		}
+	}
+	sort.Slice(dropped, func(i, j int) bool { return strings.ToLower(dropped[i]) < strings.ToLower(dropped[j]) })
+	return dropped
+}
+
+// resolveAccountIds returns cosmosdb_account_ids and the accounts of scope, deduplicated ignoring case and sorted.
+func (r *CosmosDBIpFilterBulkResource) resolveAccountIds(ctx context.Context, model *CosmosDBIpFilterBulkResourceModel) ([]string, error) {
+	ids := listToStrings(model.CosmosDBAccountIds)
+	if !model.Scope.IsNull() {
+		scope, err := resourceid.Parse(model.Scope.ValueString())
+		if err != nil {
+			return nil, err
+		}
+		accounts, err := r.client.ListCosmosDBAccounts(ctx, scope)
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range accounts {
+			ids = append(ids, account.ID)
+		}
+	}
+	seen := map[string]struct{}{}
+	accountIds := []string{}
+	for _, id := range ids {
+		if _, ok := seen[strings.ToLower(id)]; !ok {
+			seen[strings.ToLower(id)] = struct{}{}
+			accountIds = append(accountIds, id)
+		}
+	}
+	sort.Slice(accountIds, func(i, j int) bool { return strings.ToLower(accountIds[i]) < strings.ToLower(accountIds[j]) })
+	return accountIds, nil
+}
+
+// readCosmosDBAccounts reads the IP rules of every account, at most maxConcurrentAccountReads at once.
+func readCosmosDBAccounts(ctx context.Context, c *client.Client, accountIds []string) ([]*cosmosDBIpRuleAdapter, [][]string, []error) {
+	adapters := make([]*cosmosDBIpRuleAdapter, len(accountIds))
+	current := make([][]string, len(accountIds))
+	errs := make([]error, len(accountIds))
+	slots := make(chan struct{}, maxConcurrentAccountReads)
+	var wg sync.WaitGroup
+	for i, id := range accountIds {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			adapters[i] = newCosmosDBIpRuleAdapter(c, id)
+			current[i], errs[i] = adapters[i].Read(ctx)
+		}()
+	}
+	wg.Wait()
+	return adapters, current, errs
+}
+
+// previousBulkIpRules returns the managed rules of every account of state by lower-cased account ID. A nil state,
+// i.e. a resource being created, managed nothing.
+func previousBulkIpRules(ctx context.Context, state *CosmosDBIpFilterBulkResourceModel, diags *diag.Diagnostics) map[string][]string {
+	previous := map[string][]string{}
+	if state == nil || state.Accounts.IsNull() || state.Accounts.IsUnknown() {
+		return previous
+	}
+	var accounts map[string]bulkAccountModel
+	diags.Append(state.Accounts.ElementsAs(ctx, &accounts, false)...)
+	for id, account := range accounts {
+		previous[strings.ToLower(id)] = listToStrings(account.ManagedIpRules)
+	}
+	return previous
+}
+
+func bulkAccountToModel(ctx context.Context, account bulkAccount, diags *diag.Diagnostics) bulkAccountModel {
+	// A nil slice would be a null list, e.g. the rules of an account which couldn't be read.
+	list := func(values []string) types.List {
+		return stringsToList(ctx, append([]string{}, values...), diags)
+	}
+	return bulkAccountModel{
+		ManagedIpRules:   list(account.managed),
+		EffectiveIpRules: list(account.merge.Final),
+		PendingAdditions: list(account.merge.Added),
+		PendingRemovals:  list(account.merge.Removed),
+	}
+}
+
+func bulkAccountsToMap(ctx context.Context, accounts map[string]bulkAccount, diags *diag.Diagnostics) types.Map {
+	values := make(map[string]attr.Value, len(accounts))
+	for id, account := range accounts {
+		if account.unknown {
+			values[id] = types.ObjectUnknown(bulkAccountObjectType.AttrTypes)
+			continue
+		}
+		value, d := types.ObjectValueFrom(ctx, bulkAccountObjectType.AttrTypes, bulkAccountToModel(ctx, account, diags))
+		diags.Append(d...)
+		values[id] = value
+	}
+	accountsMap, d := types.MapValue(bulkAccountObjectType, values)
+	diags.Append(d...)
+	return accountsMap
+}
+
+// newBulkID returns a random ID, the resource has no Azure counterpart to take it from.
+func newBulkID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
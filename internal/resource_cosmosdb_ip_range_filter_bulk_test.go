@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"terraform-provider-azurermext/internal/testing/fakearm"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+)
+
+func TestAccCosmosDBIpRangeFilterBulk_basic(t *testing.T) {
+	server := fakearm.New(t)
+	publicId := testCosmosDBAccountId("public")
+	privateId := testCosmosDBAccountId("private")
+	missingId := testCosmosDBAccountId("missing")
+	server.AddCosmosDBAccount(publicId, []string{"1.1.1.1"})
+	server.AddCosmosDBAccount(privateId, []string{"1.1.1.1"})
+	server.UpdateCosmosDBAccount(privateId, func(account *fakearm.CosmosDBAccount) {
+		account.PublicNetworkAccess = false
+	})
+	accounts := tfjsonpath.New("accounts")
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				// The accounts which can't be previewed don't hide the preview of the others.
+				Config:             server.ProviderConfig() + testCosmosDBIpRangeFilterBulkConfig([]string{publicId, privateId, missingId}, "10.0.0.1"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPreRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectKnownValue("azurermext_cosmosdb_ip_range_filter_bulk.test",
+							accounts.AtMapKey(publicId).AtMapKey("effective_ip_rules"),
+							knownvalue.ListExact([]knownvalue.Check{knownvalue.StringExact("1.1.1.1"), knownvalue.StringExact("10.0.0.1")})),
+						plancheck.ExpectUnknownValue("azurermext_cosmosdb_ip_range_filter_bulk.test", accounts.AtMapKey(privateId)),
+						plancheck.ExpectUnknownValue("azurermext_cosmosdb_ip_range_filter_bulk.test", accounts.AtMapKey(missingId)),
+					},
+				},
+			},
+			{
+				Config: server.ProviderConfig() + testCosmosDBIpRangeFilterBulkConfig([]string{publicId}, "10.0.0.1"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckCosmosDBIpRules(server, publicId, "1.1.1.1", "10.0.0.1"),
+					testCheckCosmosDBIpRules(server, privateId, "1.1.1.1"),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter_bulk.test", "account_ids.#", "1"),
+				),
+			},
+			{
+				PreConfig: func() {
+					server.UpdateCosmosDBAccount(privateId, func(account *fakearm.CosmosDBAccount) {
+						account.PublicNetworkAccess = true
+					})
+				},
+				Config: server.ProviderConfig() + testCosmosDBIpRangeFilterBulkConfig([]string{publicId, privateId}, "10.0.0.1"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckCosmosDBIpRules(server, publicId, "1.1.1.1", "10.0.0.1"),
+					testCheckCosmosDBIpRules(server, privateId, "1.1.1.1", "10.0.0.1"),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter_bulk.test", "accounts.%", "2"),
+				),
+			},
+			{
+				// An account leaving the set loses the managed rules only.
+				Config: server.ProviderConfig() + testCosmosDBIpRangeFilterBulkConfig([]string{publicId}, "10.0.0.1"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckCosmosDBIpRules(server, publicId, "1.1.1.1", "10.0.0.1"),
+					testCheckCosmosDBIpRules(server, privateId, "1.1.1.1"),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter_bulk.test", "account_ids.#", "1"),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter_bulk.test", "accounts.%", "1"),
+					resource.TestCheckResourceAttrSet("azurermext_cosmosdb_ip_range_filter_bulk.test", "accounts."+publicId+".managed_ip_rules.#"),
+				),
+			},
+			{
+				// Removing the rules of an account left with no other rule would open it to all networks.
+				PreConfig: func() {
+					server.UpdateCosmosDBAccount(privateId, func(account *fakearm.CosmosDBAccount) {
+						account.IpRules = []string{"10.0.0.1"}
+					})
+				},
+				Config: server.ProviderConfig() + testCosmosDBIpRangeFilterBulkConfig([]string{publicId, privateId}, "10.0.0.1"),
+			},
+			{
+				Config: server.ProviderConfig() + testCosmosDBIpRangeFilterBulkConfig([]string{publicId}, "10.0.0.1"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckCosmosDBIpRules(server, privateId, "10.0.0.1"),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter_bulk.test", "accounts.%", "1"),
+				),
+			},
+		},
+	})
+}
+
+func testCosmosDBIpRangeFilterBulkConfig(accountIds []string, ipRules ...string) string {
+	return fmt.Sprintf(`
+resource "azurermext_cosmosdb_ip_range_filter_bulk" "test" {
+  cosmosdb_account_ids = ["%s"]
+  ip_rules             = ["%s"]
+}
+`, strings.Join(accountIds, `", "`), strings.Join(ipRules, `", "`))
+}
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.ResourceWithMoveState = (*CosmosDBIpFilterResource)(nil)
+)
+
+// azurermCosmosDBAccountType is the resource of the official provider whose ip_range_filter can be moved to
+// azurermext_cosmosdb_ip_range_filter.
+const azurermCosmosDBAccountType = "azurerm_cosmosdb_account"
+
+// azurermCosmosDBAccountState holds the attributes of an azurerm_cosmosdb_account state the move needs. Its schema
+// isn't declared, it has dozens of attributes varying between versions of the official provider.
+type azurermCosmosDBAccountState struct {
+	ID            string          `json:"id"`
+	IpRangeFilter json.RawMessage `json:"ip_range_filter"`
+}
+
+// ipRanges returns the rules of ip_range_filter, a comma separated string up to version 3 of the official provider
+// and a set of strings since version 4.
+func (s azurermCosmosDBAccountState) ipRanges() ([]string, error) {
+	ranges := []string{}
+	if len(s.IpRangeFilter) == 0 || string(s.IpRangeFilter) == "null" {
+		return ranges, nil
+	}
+	var values []string
+	if err := json.Unmarshal(s.IpRangeFilter, &values); err != nil {
+		var value string
+		if err := json.Unmarshal(s.IpRangeFilter, &value); err != nil {
+			return nil, err
+		}
+		values = strings.Split(value, ",")
+	}
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			ranges = append(ranges, value)
+		}
+	}
+	return ranges, nil
+}
+
+func (r *CosmosDBIpFilterResource) MoveState(_ context.Context) []resource.StateMover {
+	return []resource.StateMover{
+		{StateMover: moveCosmosDBAccountIpRangeFilter},
+	}
+}
+
+// moveCosmosDBAccountIpRangeFilter moves the ip_range_filter of an azurerm_cosmosdb_account to the resource, so that
+// a `moved` block hands the firewall over without any change to the account. Every rule becomes a managed rule which
+// never expires, as if the resource had written them.
+func moveCosmosDBAccountIpRangeFilter(ctx context.Context, req resource.MoveStateRequest, resp *resource.MoveStateResponse) {
+	if req.SourceTypeName != azurermCosmosDBAccountType || !strings.HasSuffix(req.SourceProviderAddress, "hashicorp/azurerm") {
+		return
+	}
+	var source azurermCosmosDBAccountState
+	if req.SourceRawState == nil || json.Unmarshal(req.SourceRawState.JSON, &source) != nil || source.ID == "" {
+		resp.Diagnostics.AddError(
+			"Invalid azurerm_cosmosdb_account state",
+			"The state of the azurerm_cosmosdb_account to move has no id, it can't be moved to this resource.",
+		)
+		return
+	}
+	ipRanges, err := source.ipRanges()
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("ip_rules"),
+			"Invalid azurerm_cosmosdb_account state",
+			"The ip_range_filter of azurerm_cosmosdb_account "+source.ID+" can't be read: "+err.Error())
+		return
+	}
+
+	ipRules := []ipRuleModel{}
+	for _, ip := range ipRanges {
+		ipRules = append(ipRules, newIpRule(ip))
+	}
+	ipRangesList := stringsToList(ctx, ipRanges, &resp.Diagnostics)
+	emptyList := stringsToList(ctx, []string{}, &resp.Diagnostics)
+	moved := CosmosDBMongoDBIpFilterResourceModel{
+		ID:                  types.StringValue(source.ID),
+		CosmosDBAccountId:   types.StringValue(source.ID),
+		IpRules:             ipRulesToList(ctx, ipRules, &resp.Diagnostics),
+		EffectiveIpRules:    ipRangesList,
+		PendingAdditions:    emptyList,
+		PendingRemovals:     emptyList,
+		AllIpRules:          ipRangesList,
+		UnmanagedIpRules:    emptyList,
+		UnmanagedRulePolicy: types.StringValue(unmanagedRulePolicyIgnore),
+		Mode:                types.StringValue(ipRulesModeAdditive),
+		ServiceTags:         types.ListNull(types.StringType),
+		ServiceTagsFile:     types.StringNull(),
+		ServiceTagIpRules:   emptyList,
+		Aggregate:           types.BoolValue(false),
+		ManagedIpRules:      ipRangesList,
+		MetadataTag:         types.StringValue(defaultIpRuleMetadataTag),
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.TargetState.Set(ctx, moved)...)
+}
@@ -0,0 +1,435 @@
+package internal
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"strings"
+	"terraform-provider-azurermext/internal/client"
+	"terraform-provider-azurermext/internal/rulemetadata"
+	"terraform-provider-azurermext/internal/servicetags"
+	"terraform-provider-azurermext/internal/testing/fakearm"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccCosmosDBIpRangeFilter_basic(t *testing.T) {
+	server := fakearm.New(t)
+	accountId := testCosmosDBAccountId("basic")
+	server.AddCosmosDBAccount(accountId, []string{"1.1.1.1"})
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + testCosmosDBIpRangeFilterConfig(accountId, `{ ip = "10.0.0.1" }`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckCosmosDBIpRules(server, accountId, "1.1.1.1", "10.0.0.1"),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter.test", "id", accountId),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter.test", "managed_ip_rules.#", "1"),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter.test", "unmanaged_ip_rules.0", "1.1.1.1"),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter.test", "effective_ip_rules.#", "2"),
+				),
+			},
+			{
+				Config: server.ProviderConfig() + testCosmosDBIpRangeFilterConfig(accountId, `{ ip = "10.0.0.2" }, { ip = "10.0.0.3" }`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckCosmosDBIpRules(server, accountId, "1.1.1.1", "10.0.0.2", "10.0.0.3"),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter.test", "managed_ip_rules.#", "2"),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter.test", "pending_additions.#", "2"),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter.test", "pending_removals.0", "10.0.0.1"),
+				),
+			},
+		},
+		// Destroying the resource leaves the account untouched.
+		CheckDestroy: testCheckCosmosDBIpRules(server, accountId, "1.1.1.1", "10.0.0.2", "10.0.0.3"),
+	})
+}
+
+func TestAccCosmosDBIpRangeFilter_foreignRulesKept(t *testing.T) {
+	server := fakearm.New(t)
+	accountId := testCosmosDBAccountId("foreign")
+	// An account without IP rules is open to all networks, which the additive mode leaves as is.
+	server.AddCosmosDBAccount(accountId, []string{"1.1.1.1"})
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + testCosmosDBIpRangeFilterConfig(accountId, `{ ip = "10.0.0.1" }`),
+				Check:  testCheckCosmosDBIpRules(server, accountId, "1.1.1.1", "10.0.0.1"),
+			},
+			{
+				// A rule added outside of Terraform neither shows as drift nor is removed.
+				PreConfig: func() {
+					server.UpdateCosmosDBAccount(accountId, func(account *fakearm.CosmosDBAccount) {
+						account.IpRules = append(account.IpRules, "2.2.2.2")
+					})
+				},
+				Config:   server.ProviderConfig() + testCosmosDBIpRangeFilterConfig(accountId, `{ ip = "10.0.0.1" }`),
+				PlanOnly: true,
+			},
+			{
+				Config: server.ProviderConfig() + testCosmosDBIpRangeFilterConfig(accountId, ``),
+				Check:  testCheckCosmosDBIpRules(server, accountId, "1.1.1.1", "2.2.2.2"),
+			},
+		},
+	})
+}
+
+func TestAccCosmosDBIpRangeFilter_authoritativeDeny(t *testing.T) {
+	server := fakearm.New(t)
+	accountId := testCosmosDBAccountId("authoritative")
+	server.AddCosmosDBAccount(accountId, []string{"1.1.1.1"})
+	config := server.ProviderConfig() + fmt.Sprintf(`
+resource "azurermext_cosmosdb_ip_range_filter" "test" {
+  cosmosdb_account_id   = %q
+  ip_rules              = [{ ip = "10.0.0.1" }]
+  mode                  = "authoritative"
+  unmanaged_rule_policy = "deny"
+}
+`, accountId)
+
+	// The unmanaged rules don't fail the plan since the apply removes them, so the resource converges.
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check:  testCheckCosmosDBIpRules(server, accountId, "10.0.0.1"),
+			},
+			{
+				PreConfig: func() {
+					server.UpdateCosmosDBAccount(accountId, func(account *fakearm.CosmosDBAccount) {
+						account.IpRules = append(account.IpRules, "2.2.2.2")
+					})
+				},
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckCosmosDBIpRules(server, accountId, "10.0.0.1"),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter.test", "pending_removals.0", "2.2.2.2"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccCosmosDBIpRangeFilter_sharedMetadataTag(t *testing.T) {
+	server := fakearm.New(t)
+	accountId := testCosmosDBAccountId("shared")
+	// The rules are already there, so that both resources only write their metadata, at once.
+	server.AddCosmosDBAccount(accountId, []string{"10.0.0.1", "10.0.0.2"})
+	config := server.ProviderConfig() + fmt.Sprintf(`
+resource "azurermext_cosmosdb_ip_range_filter" "a" {
+  cosmosdb_account_id = %[1]q
+  ip_rules            = [{ ip = "10.0.0.1", description = "office", owner = "team-a" }]
+}
+
+resource "azurermext_cosmosdb_ip_range_filter" "b" {
+  cosmosdb_account_id = %[1]q
+  ip_rules            = [{ ip = "10.0.0.2", description = "vpn", owner = "team-b" }]
+}
+`, accountId)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: func(*terraform.State) error {
+					account, _ := server.CosmosDBAccount(accountId)
+					metadata, err := rulemetadata.Decode(defaultIpRuleMetadataTag, account.Tags)
+					if err != nil {
+						return err
+					}
+					want := map[string]rulemetadata.Metadata{
+						"10.0.0.1": {Description: "office", Owner: "team-a"},
+						"10.0.0.2": {Description: "vpn", Owner: "team-b"},
+					}
+					if !maps.Equal(metadata, want) {
+						return fmt.Errorf("CosmosDB account %s has IP rule metadata %v, want %v", accountId, metadata, want)
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestAccCosmosDBIpRangeFilter_missingTagsPermission(t *testing.T) {
+	server := fakearm.New(t)
+	accountId := testCosmosDBAccountId("permissions")
+	server.AddCosmosDBAccount(accountId, []string{"1.1.1.1"})
+	server.SetPermissions(client.Permission{Actions: []string{"Microsoft.DocumentDB/*"}})
+	config := fmt.Sprintf(`
+provider "azurermext" {
+  tenant_id         = %q
+  client_id         = %q
+  client_secret     = %q
+  check_permissions = true
+}
+`, fakearm.TenantID, fakearm.ClientID, fakearm.ClientSecret) + testCosmosDBIpRangeFilterConfig(accountId, `{ ip = "10.0.0.1", owner = "team-a" }`)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				Config:      config,
+				ExpectError: regexp.MustCompile(`Microsoft\.Resources/tags/write`),
+			},
+		},
+	})
+}
+
+func TestAccCosmosDBIpRangeFilter_stateOnlyChange(t *testing.T) {
+	server := fakearm.New(t)
+	accountId := testCosmosDBAccountId("state-only")
+	server.AddCosmosDBAccount(accountId, []string{"1.1.1.1"})
+	updates := 0
+
+	// Changes which leave the firewall as is only write the state and the metadata tag, never the account.
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + testCosmosDBIpRangeFilterConfig(accountId, `{ ip = "10.0.0.1" }`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckCosmosDBIpRules(server, accountId, "1.1.1.1", "10.0.0.1"),
+					func(*terraform.State) error {
+						if updates = testCosmosDBAccountUpdates(server, accountId); updates == 0 {
+							return fmt.Errorf("CosmosDB account %s wasn't updated", accountId)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config: server.ProviderConfig() + testCosmosDBIpRangeFilterConfig(accountId, `{ ip = "10.0.0.1", description = "office", owner = "team-a" }`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckCosmosDBIpRules(server, accountId, "1.1.1.1", "10.0.0.1"),
+					testCheckNoCosmosDBAccountUpdate(server, accountId, &updates),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter.test", "ip_rules.0.description", "office"),
+				),
+			},
+			{
+				Config: server.ProviderConfig() + testCosmosDBIpRangeFilterConfig(accountId, `{ ip = "10.0.0.1", expires_at = "2999-01-01T00:00:00Z" }`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckCosmosDBIpRules(server, accountId, "1.1.1.1", "10.0.0.1"),
+					testCheckNoCosmosDBAccountUpdate(server, accountId, &updates),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter.test", "ip_rules.0.expires_at", "2999-01-01T00:00:00Z"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccCosmosDBIpRangeFilter_throttled(t *testing.T) {
+	server := fakearm.New(t)
+	accountId := testCosmosDBAccountId("throttled")
+	server.AddCosmosDBAccount(accountId, []string{"1.1.1.1"})
+
+	// The 429 answers are retried after their Retry-After instead of failing the apply.
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				PreConfig: func() { server.Throttle(2) },
+				Config:    server.ProviderConfig() + testCosmosDBIpRangeFilterConfig(accountId, `{ ip = "10.0.0.1" }`),
+				Check:     testCheckCosmosDBIpRules(server, accountId, "1.1.1.1", "10.0.0.1"),
+			},
+		},
+	})
+}
+
+func TestAccCosmosDBIpRangeFilter_updateFailed(t *testing.T) {
+	server := fakearm.New(t)
+	accountId := testCosmosDBAccountId("failed")
+	server.AddCosmosDBAccount(accountId, []string{"1.1.1.1"})
+	config := server.ProviderConfig() + testCosmosDBIpRangeFilterConfig(accountId, `{ ip = "10.0.0.1" }`)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				// The error of the operation is rendered with the IDs to give to the support.
+				PreConfig:   func() { server.FailNextUpdate("BadRequest", "Invalid IP range") },
+				Config:      config,
+				ExpectError: regexp.MustCompile(`(?s)status\s+Failed.*Code:\s+BadRequest.*Message:\s+Invalid\s+IP\s+range.*Request\s+ID:\s+fakearm-request-.*Correlation\s+request\s+ID:\s+fakearm-correlation-`),
+			},
+			{
+				PreConfig: func() {
+					if err := testCheckCosmosDBIpRules(server, accountId, "1.1.1.1")(nil); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: config,
+				Check:  testCheckCosmosDBIpRules(server, accountId, "1.1.1.1", "10.0.0.1"),
+			},
+		},
+	})
+}
+
+func TestAccCosmosDBIpRangeFilter_updateInProgress(t *testing.T) {
+	server := fakearm.New(t)
+	accountId := testCosmosDBAccountId("in-progress")
+	server.AddCosmosDBAccount(accountId, []string{"1.1.1.1"})
+	server.SetUpdateDelay(200 * time.Millisecond)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + testCosmosDBIpRangeFilterConfig(accountId, `{ ip = "10.0.0.1" }`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckCosmosDBIpRules(server, accountId, "1.1.1.1", "10.0.0.1"),
+					func(*terraform.State) error {
+						polls := 0
+						for _, request := range server.Requests() {
+							if request.Method == http.MethodGet && strings.HasPrefix(request.Path, "/fakearm/operations/") {
+								polls++
+							}
+						}
+						if polls < 2 {
+							return fmt.Errorf("the update was polled %d times, want it polled until it completed", polls)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccCosmosDBIpRangeFilter_deleted(t *testing.T) {
+	server := fakearm.New(t)
+	accountId := testCosmosDBAccountId("deleted")
+	server.AddCosmosDBAccount(accountId, []string{"1.1.1.1"})
+	config := server.ProviderConfig() + testCosmosDBIpRangeFilterConfig(accountId, `{ ip = "10.0.0.1" }`)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check:  testCheckCosmosDBIpRules(server, accountId, "1.1.1.1", "10.0.0.1"),
+			},
+			{
+				// The refresh drops the filter of a deleted account from the state, so it's planned again.
+				PreConfig:          func() { server.RemoveCosmosDBAccount(accountId) },
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PostApplyPostRefresh: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("azurermext_cosmosdb_ip_range_filter.test", plancheck.ResourceActionCreate),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestAccCosmosDBIpRangeFilter_crossTenant(t *testing.T) {
+	const auxiliaryTenant = "00000000-0000-0000-0000-00000000cccc"
+	server := fakearm.New(t)
+	accountId := "/subscriptions/00000000-0000-0000-0000-000000000002/resourceGroups/rg/providers/Microsoft.DocumentDB/databaseAccounts/foreign"
+	server.SetSubscriptionTenant("00000000-0000-0000-0000-000000000002", auxiliaryTenant)
+	server.AddCosmosDBAccount(accountId, []string{"1.1.1.1"})
+	config := fmt.Sprintf(`
+provider "azurermext" {
+  tenant_id            = %q
+  client_id            = %q
+  client_secret        = %q
+  auxiliary_tenant_ids = [%q]
+}
+`, fakearm.TenantID, fakearm.ClientID, fakearm.ClientSecret, auxiliaryTenant) + testCosmosDBIpRangeFilterConfig(accountId, `{ ip = "10.0.0.1" }`)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check:  testCheckCosmosDBIpRules(server, accountId, "1.1.1.1", "10.0.0.1"),
+			},
+		},
+	})
+}
+
+func TestAccCosmosDBIpRangeFilter_serviceTags(t *testing.T) {
+	server := fakearm.New(t)
+	accountId := testCosmosDBAccountId("servicetags")
+	server.AddCosmosDBAccount(accountId, []string{"1.1.1.1"})
+	server.SetServiceTags(servicetags.ServiceTag{
+		Name: "DataFactory.WestEurope",
+		ID:   "DataFactory.WestEurope",
+		Properties: servicetags.ServiceTagProperties{
+			AddressPrefixes: []string{"10.0.0.128/25", "2001:db8::/32", "10.0.0.0/25"},
+		},
+	})
+	config := func(serviceTags string) string {
+		return server.ProviderConfig() + fmt.Sprintf(`
+resource "azurermext_cosmosdb_ip_range_filter" "test" {
+  cosmosdb_account_id = %q
+  ip_rules            = [{ ip = "10.0.1.0/24" }]
+  service_tags        = [%s]
+  aggregate           = true
+}
+`, accountId, serviceTags)
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				Config:      config(`"DataFactory.WestEurope", "Storage"`),
+				ExpectError: regexp.MustCompile(`unknown service tags: Storage`),
+			},
+			{
+				// The IPv6 prefix is left out and the rest merged with ip_rules.
+				Config: config(`"datafactory.westeurope"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckCosmosDBIpRules(server, accountId, "1.1.1.1", "10.0.0.0/23"),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter.test", "service_tag_ip_rules.#", "1"),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter.test", "service_tag_ip_rules.0", "10.0.0.0/24"),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter.test", "managed_ip_rules.#", "1"),
+					resource.TestCheckResourceAttr("azurermext_cosmosdb_ip_range_filter.test", "managed_ip_rules.0", "10.0.0.0/23"),
+				),
+			},
+		},
+	})
+}
+
+// testCosmosDBAccountUpdates counts the updates of a fake CosmosDB account, tag updates excluded.
+func testCosmosDBAccountUpdates(server *fakearm.Server, accountId string) int {
+	count := 0
+	for _, request := range server.Requests() {
+		if request.Method == http.MethodPatch && strings.EqualFold(request.Path, accountId) {
+			count++
+		}
+	}
+	return count
+}
+
+// testCheckNoCosmosDBAccountUpdate checks that a fake CosmosDB account wasn't updated since it had *updates updates.
+func testCheckNoCosmosDBAccountUpdate(server *fakearm.Server, accountId string, updates *int) func(*terraform.State) error {
+	return func(*terraform.State) error {
+		if got := testCosmosDBAccountUpdates(server, accountId); got != *updates {
+			return fmt.Errorf("CosmosDB account %s was updated %d times, want no update", accountId, got-*updates)
+		}
+		return nil
+	}
+}
+
+func testCosmosDBIpRangeFilterConfig(accountId, ipRules string) string {
+	return fmt.Sprintf(`
+resource "azurermext_cosmosdb_ip_range_filter" "test" {
+  cosmosdb_account_id = %q
+  ip_rules            = [%s]
+}
+`, accountId, ipRules)
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.ResourceWithUpgradeState = (*CosmosDBIpFilterResource)(nil)
+)
+
+// cosmosDBIpFilterResourceModelV1 is the state of version 1, the first released schema, where ip_rules was a list of
+// strings. The first releases only wrote id, cosmosdb_account_id and ip_rules, the other attributes are null in
+// their states.
+type cosmosDBIpFilterResourceModelV1 struct {
+	ID                  types.String `tfsdk:"id"`
+	CosmosDBAccountId   types.String `tfsdk:"cosmosdb_account_id"`
+	IpRules             types.List   `tfsdk:"ip_rules"`
+	EffectiveIpRules    types.List   `tfsdk:"effective_ip_rules"`
+	PendingAdditions    types.List   `tfsdk:"pending_additions"`
+	PendingRemovals     types.List   `tfsdk:"pending_removals"`
+	AllIpRules          types.List   `tfsdk:"all_ip_rules"`
+	UnmanagedIpRules    types.List   `tfsdk:"unmanaged_ip_rules"`
+	UnmanagedRulePolicy types.String `tfsdk:"unmanaged_rule_policy"`
+	Mode                types.String `tfsdk:"mode"`
+}
+
+func cosmosDBIpFilterSchemaV1() *schema.Schema {
+	stringList := func() schema.ListAttribute {
+		return schema.ListAttribute{ElementType: types.StringType, Computed: true}
+	}
+	return &schema.Schema{
+		Version: 1,
+		Attributes: map[string]schema.Attribute{
+			"id":                    schema.StringAttribute{Computed: true},
+			"cosmosdb_account_id":   schema.StringAttribute{Required: true},
+			"ip_rules":              schema.ListAttribute{ElementType: types.StringType, Required: true},
+			"effective_ip_rules":    stringList(),
+			"pending_additions":     stringList(),
+			"pending_removals":      stringList(),
+			"all_ip_rules":          stringList(),
+			"unmanaged_ip_rules":    stringList(),
+			"unmanaged_rule_policy": schema.StringAttribute{Optional: true, Computed: true},
+			"mode":                  schema.StringAttribute{Optional: true, Computed: true},
+		},
+	}
+}
+
+func (r *CosmosDBIpFilterResource) UpgradeState(_ context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		1: {
+			PriorSchema:   cosmosDBIpFilterSchemaV1(),
+			StateUpgrader: upgradeCosmosDBIpFilterStateV1,
+		},
+	}
+}
+
+func upgradeCosmosDBIpFilterStateV1(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	var prior cosmosDBIpFilterResourceModelV1
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	upgraded := upgradeCosmosDBIpFilterModelV1(ctx, prior, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, upgraded)...)
+}
+
+// upgradeCosmosDBIpFilterModelV1 turns every IP of ip_rules into a rule which never expires.
+func upgradeCosmosDBIpFilterModelV1(ctx context.Context, prior cosmosDBIpFilterResourceModelV1, diags *diag.Diagnostics) CosmosDBMongoDBIpFilterResourceModel {
+	ipRules := []ipRuleModel{}
+	for _, ip := range listToStrings(prior.IpRules) {
+		ipRules = append(ipRules, newIpRule(ip))
+	}
+	upgraded := CosmosDBMongoDBIpFilterResourceModel{
+		ID:                  prior.ID,
+		CosmosDBAccountId:   prior.CosmosDBAccountId,
+		IpRules:             ipRulesToList(ctx, ipRules, diags),
+		EffectiveIpRules:    prior.EffectiveIpRules,
+		PendingAdditions:    prior.PendingAdditions,
+		PendingRemovals:     prior.PendingRemovals,
+		AllIpRules:          prior.AllIpRules,
+		UnmanagedIpRules:    prior.UnmanagedIpRules,
+		UnmanagedRulePolicy: prior.UnmanagedRulePolicy,
+		Mode:                prior.Mode,
+		ServiceTags:         types.ListNull(types.StringType),
+		ServiceTagsFile:     types.StringNull(),
+		ServiceTagIpRules:   types.ListNull(types.StringType),
+		Aggregate:           types.BoolValue(false),
+		ManagedIpRules:      types.ListNull(types.StringType),
+		MetadataTag:         types.StringValue(defaultIpRuleMetadataTag),
+	}
+	if upgraded.UnmanagedRulePolicy.IsNull() {
+		upgraded.UnmanagedRulePolicy = types.StringValue(unmanagedRulePolicyIgnore)
+	}
+	if upgraded.Mode.IsNull() {
+		upgraded.Mode = types.StringValue(ipRulesModeAdditive)
+	}
+	return upgraded
+}
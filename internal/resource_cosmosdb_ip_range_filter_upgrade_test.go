@@ -0,0 +1,273 @@
+package internal
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+const testCosmosDBIpRangeFilterType = "azurermext_cosmosdb_ip_range_filter"
+
+func testProviderServer(t *testing.T) tfprotov6.ProviderServer {
+	t.Helper()
+	server, err := providerserver.NewProtocol6WithError(NewProvider())()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+// testDecodeCosmosDBIpFilterState decodes a state returned by the provider server with the current schema.
+func testDecodeCosmosDBIpFilterState(t *testing.T, state *tfprotov6.DynamicValue) CosmosDBMongoDBIpFilterResourceModel {
+	t.Helper()
+	ctx := context.Background()
+	if state == nil {
+		t.Fatal("no state returned")
+	}
+	var schemaResp resource.SchemaResponse
+	NewCosmosDBMongoDBIpFilterResource().Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	raw, err := state.Unmarshal(schemaResp.Schema.Type().TerraformType(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var model CosmosDBMongoDBIpFilterResourceModel
+	if diags := (tfsdk.State{Raw: raw, Schema: schemaResp.Schema}).Get(ctx, &model); diags.HasError() {
+		t.Fatal(diags)
+	}
+	return model
+}
+
+// testIpRuleIps returns the IPs of the ip_rules of a state, failing if any rule has anything but an IP.
+func testIpRuleIps(t *testing.T, model CosmosDBMongoDBIpFilterResourceModel) []string {
+	t.Helper()
+	var rules []ipRuleModel
+	if diags := model.IpRules.ElementsAs(context.Background(), &rules, false); diags.HasError() {
+		t.Fatal(diags)
+	}
+	ips := []string{}
+	for _, rule := range rules {
+		if !rule.ExpiresAt.IsNull() || !rule.Description.IsNull() || !rule.Owner.IsNull() {
+			t.Errorf("rule %s: got %v, want only an IP", rule.Ip.ValueString(), rule)
+		}
+		ips = append(ips, rule.Ip.ValueString())
+	}
+	return ips
+}
+
+func TestCosmosDBIpRangeFilterUpgradeState(t *testing.T) {
+	accountId := testCosmosDBAccountId("upgrade")
+	cases := []struct {
+		name      string
+		raw       string
+		ipRules   []string
+		effective []string
+		policy    string
+		mode      string
+	}{
+		{
+			name:    "first release",
+			raw:     `{"id":"` + accountId + `","cosmosdb_account_id":"` + accountId + `","ip_rules":["10.0.0.1","10.0.0.2"]}`,
+			ipRules: []string{"10.0.0.1", "10.0.0.2"},
+			policy:  unmanagedRulePolicyIgnore,
+			mode:    ipRulesModeAdditive,
+		},
+		{
+			name: "every attribute",
+			raw: `{"id":"` + accountId + `","cosmosdb_account_id":"` + accountId + `","ip_rules":["10.0.0.1"],` +
+				`"effective_ip_rules":["1.1.1.1","10.0.0.1"],"pending_additions":["10.0.0.1"],"pending_removals":[],` +
+				`"all_ip_rules":["1.1.1.1","10.0.0.1"],"unmanaged_ip_rules":["1.1.1.1"],` +
+				`"unmanaged_rule_policy":"warn","mode":"authoritative"}`,
+			ipRules:   []string{"10.0.0.1"},
+			effective: []string{"1.1.1.1", "10.0.0.1"},
+			policy:    unmanagedRulePolicyWarn,
+			mode:      ipRulesModeAuthoritative,
+		},
+		{
+			name:    "no rules",
+			raw:     `{"id":"` + accountId + `","cosmosdb_account_id":"` + accountId + `","ip_rules":[]}`,
+			ipRules: []string{},
+			policy:  unmanagedRulePolicyIgnore,
+			mode:    ipRulesModeAdditive,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := testProviderServer(t).UpgradeResourceState(context.Background(), &tfprotov6.UpgradeResourceStateRequest{
+				TypeName: testCosmosDBIpRangeFilterType,
+				Version:  1,
+				RawState: &tfprotov6.RawState{JSON: []byte(tc.raw)},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range resp.Diagnostics {
+				t.Fatalf("%s: %s", d.Summary, d.Detail)
+			}
+			state := testDecodeCosmosDBIpFilterState(t, resp.UpgradedState)
+
+			if got := state.ID.ValueString(); got != accountId {
+				t.Errorf("id: got %q, want %q", got, accountId)
+			}
+			if got := state.CosmosDBAccountId.ValueString(); got != accountId {
+				t.Errorf("cosmosdb_account_id: got %q, want %q", got, accountId)
+			}
+			if got := testIpRuleIps(t, state); !slices.Equal(got, tc.ipRules) {
+				t.Errorf("ip_rules: got %v, want %v", got, tc.ipRules)
+			}
+			if tc.effective == nil {
+				if !state.EffectiveIpRules.IsNull() {
+					t.Errorf("effective_ip_rules: got %v, want null", state.EffectiveIpRules)
+				}
+			} else if got := listToStrings(state.EffectiveIpRules); !slices.Equal(got, tc.effective) {
+				t.Errorf("effective_ip_rules: got %v, want %v", got, tc.effective)
+			}
+			if got := state.UnmanagedRulePolicy.ValueString(); got != tc.policy {
+				t.Errorf("unmanaged_rule_policy: got %q, want %q", got, tc.policy)
+			}
+			if got := state.Mode.ValueString(); got != tc.mode {
+				t.Errorf("mode: got %q, want %q", got, tc.mode)
+			}
+			if got := state.MetadataTag.ValueString(); got != defaultIpRuleMetadataTag {
+				t.Errorf("metadata_tag: got %q, want %q", got, defaultIpRuleMetadataTag)
+			}
+			if state.Aggregate.ValueBool() {
+				t.Error("aggregate: got true, want false")
+			}
+			if !state.ServiceTags.IsNull() || !state.ServiceTagsFile.IsNull() {
+				t.Errorf("service_tags: got %v and %v, want null", state.ServiceTags, state.ServiceTagsFile)
+			}
+		})
+	}
+}
+
+func TestCosmosDBIpRangeFilterMoveState(t *testing.T) {
+	accountId := testCosmosDBAccountId("move")
+	cases := []struct {
+		name     string
+		provider string
+		typeName string
+		raw      string
+		want     []string
+		// skipped is set when the mover must leave the move to another mover.
+		skipped bool
+		wantErr bool
+	}{
+		{
+			name:     "azurerm 3.x string",
+			provider: "registry.terraform.io/hashicorp/azurerm",
+			typeName: azurermCosmosDBAccountType,
+			raw:      `{"id":"` + accountId + `","name":"move","ip_range_filter":"10.0.0.1, 10.0.0.0/24,,"}`,
+			want:     []string{"10.0.0.1", "10.0.0.0/24"},
+		},
+		{
+			name:     "azurerm 4.x set",
+			provider: "registry.terraform.io/hashicorp/azurerm",
+			typeName: azurermCosmosDBAccountType,
+			raw:      `{"id":"` + accountId + `","name":"move","ip_range_filter":["10.0.0.1","10.0.0.0/24"]}`,
+			want:     []string{"10.0.0.1", "10.0.0.0/24"},
+		},
+		{
+			name:     "null filter",
+			provider: "registry.terraform.io/hashicorp/azurerm",
+			typeName: azurermCosmosDBAccountType,
+			raw:      `{"id":"` + accountId + `","ip_range_filter":null}`,
+			want:     []string{},
+		},
+		{
+			name:     "empty 3.x string",
+			provider: "registry.terraform.io/hashicorp/azurerm",
+			typeName: azurermCosmosDBAccountType,
+			raw:      `{"id":"` + accountId + `","ip_range_filter":""}`,
+			want:     []string{},
+		},
+		{
+			name:     "missing id",
+			provider: "registry.terraform.io/hashicorp/azurerm",
+			typeName: azurermCosmosDBAccountType,
+			raw:      `{"ip_range_filter":"10.0.0.1"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "invalid filter",
+			provider: "registry.terraform.io/hashicorp/azurerm",
+			typeName: azurermCosmosDBAccountType,
+			raw:      `{"id":"` + accountId + `","ip_range_filter":42}`,
+			wantErr:  true,
+		},
+		{
+			name:     "other resource",
+			provider: "registry.terraform.io/hashicorp/azurerm",
+			typeName: "azurerm_storage_account",
+			raw:      `{"id":"` + accountId + `"}`,
+			skipped:  true,
+		},
+		{
+			name:     "other provider",
+			provider: "registry.terraform.io/example/azurerm",
+			typeName: azurermCosmosDBAccountType,
+			raw:      `{"id":"` + accountId + `","ip_range_filter":"10.0.0.1"}`,
+			skipped:  true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := testProviderServer(t).MoveResourceState(context.Background(), &tfprotov6.MoveResourceStateRequest{
+				SourceProviderAddress: tc.provider,
+				SourceTypeName:        tc.typeName,
+				SourceSchemaVersion:   0,
+				SourceState:           &tfprotov6.RawState{JSON: []byte(tc.raw)},
+				TargetTypeName:        testCosmosDBIpRangeFilterType,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// When no mover handles the source, the framework reports that the move is unsupported.
+			if tc.wantErr || tc.skipped {
+				if len(resp.Diagnostics) == 0 {
+					t.Fatal("got no error")
+				}
+				if tc.skipped && resp.Diagnostics[0].Summary != "Unable to Move Resource State" {
+					t.Fatalf("got %q, want the move to be unsupported", resp.Diagnostics[0].Summary)
+				}
+				return
+			}
+			for _, d := range resp.Diagnostics {
+				t.Fatalf("%s: %s", d.Summary, d.Detail)
+			}
+			state := testDecodeCosmosDBIpFilterState(t, resp.TargetState)
+
+			if got := state.ID.ValueString(); got != accountId {
+				t.Errorf("id: got %q, want %q", got, accountId)
+			}
+			if got := state.CosmosDBAccountId.ValueString(); got != accountId {
+				t.Errorf("cosmosdb_account_id: got %q, want %q", got, accountId)
+			}
+			if got := testIpRuleIps(t, state); !slices.Equal(got, tc.want) {
+				t.Errorf("ip_rules: got %v, want %v", got, tc.want)
+			}
+			for name, list := range map[string][]string{
+				"effective_ip_rules": listToStrings(state.EffectiveIpRules),
+				"all_ip_rules":       listToStrings(state.AllIpRules),
+				"managed_ip_rules":   listToStrings(state.ManagedIpRules),
+			} {
+				if !slices.Equal(list, tc.want) {
+					t.Errorf("%s: got %v, want %v", name, list, tc.want)
+				}
+			}
+			if got := listToStrings(state.UnmanagedIpRules); len(got) != 0 {
+				t.Errorf("unmanaged_ip_rules: got %v, want none", got)
+			}
+			if got := state.Mode.ValueString(); got != ipRulesModeAdditive {
+				t.Errorf("mode: got %q, want %q", got, ipRulesModeAdditive)
+			}
+			if got := state.UnmanagedRulePolicy.ValueString(); got != unmanagedRulePolicyIgnore {
+				t.Errorf("unmanaged_rule_policy: got %q, want %q", got, unmanagedRulePolicyIgnore)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"terraform-provider-azurermext/internal/additive"
+	"terraform-provider-azurermext/internal/client"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var (
+	_ resource.ResourceWithConfigure  = (*ipRuleFilterResource)(nil)
+	_ resource.ResourceWithModifyPlan = (*ipRuleFilterResource)(nil)
+)
+
+// ipRuleFilterService describes one Azure service exposing an IP rule list.
+// Every service gets the same additive behavior as azurermext_cosmosdb_ip_range_filter.
+type ipRuleFilterService struct {
+	// typeName is appended to the provider type name, e.g. "_container_registry_ip_rule_filter".
+	typeName    string
+	description string
+	// targetAttribute is the name of the attribute holding the Azure resource ID, e.g. "container_registry_id".
+	targetAttribute   string
+	targetDescription string
+	// targetType is the resource type expected in targetAttribute, e.g. "Microsoft.ContainerRegistry/registries".
+	targetType string
+	// displayName is used in diagnostics, e.g. "Container Registry".
+	displayName string
+	// requiredActions are the Azure RBAC actions needed on the target, verified at plan time when the provider's
+	// check_permissions is enabled.
+	requiredActions []string
+	newAdapter      func(c *client.Client, targetId string) ipRuleAdapter
+}
+
+// ipRuleAdapter reads and writes the IP rule list of a single Azure resource.
+type ipRuleAdapter interface {
+	additive.Adapter[string]
+	// resourceID and publicNetworkAccess describe the target as of the last Read.
+	resourceID() string
+	publicNetworkAccess() bool
+}
+
+// ipRuleAdapterBase implements the parts of ipRuleAdapter shared by every service.
+// Write implementations are expected to store the operation they get back in operation.
+type ipRuleAdapterBase struct {
+	additive.Strings
+	client    *client.Client
+	id        string
+	public    bool
+	operation *client.Operation
+}
+
+func (a *ipRuleAdapterBase) resourceID() string {
+	return a.id
+}
+
+func (a *ipRuleAdapterBase) publicNetworkAccess() bool {
+	return a.public
+}
+
+func (a *ipRuleAdapterBase) Poll(ctx context.Context) error {
+	return a.operation.Wait(ctx)
+}
+
+type ipRuleFilterResource struct {
+	client  *client.Client
+	service ipRuleFilterService
+}
+
+type ipRuleFilterResourceModel struct {
+	ID       types.String
+	TargetId types.String
+	IpRules  types.List
+}
+
+// attributeGetter and attributeSetter are satisfied by tfsdk.State, tfsdk.Plan and tfsdk.Config.
+// The target attribute name differs for every service, so the model can't be decoded with struct tags.
+type attributeGetter interface {
+	GetAttribute(ctx context.Context, path path.Path, target interface{}) diag.Diagnostics
+}
+
+type attributeSetter interface {
+	SetAttribute(ctx context.Context, path path.Path, val interface{}) diag.Diagnostics
+}
+
+func (r *ipRuleFilterResource) getModel(ctx context.Context, from attributeGetter, model *ipRuleFilterResourceModel) (diags diag.Diagnostics) {
+	diags.Append(from.GetAttribute(ctx, path.Root("id"), &model.ID)...)
+	diags.Append(from.GetAttribute(ctx, path.Root(r.service.targetAttribute), &model.TargetId)...)
+	diags.Append(from.GetAttribute(ctx, path.Root("ip_rules"), &model.IpRules)...)
+	return diags
+}
+
+func (r *ipRuleFilterResource) setModel(ctx context.Context, to attributeSetter, model *ipRuleFilterResourceModel) (diags diag.Diagnostics) {
+	diags.Append(to.SetAttribute(ctx, path.Root("id"), model.ID)...)
+	diags.Append(to.SetAttribute(ctx, path.Root(r.service.targetAttribute), model.TargetId)...)
+	diags.Append(to.SetAttribute(ctx, path.Root("ip_rules"), model.IpRules)...)
+	return diags
+}
+
+func (r *ipRuleFilterResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + r.service.typeName
+}
+
+func (r *ipRuleFilterResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*client.Client)
+}
+
+func (r *ipRuleFilterResource) Schema(_ context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: r.service.description,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+				Computed:      true,
+			},
+			r.service.targetAttribute: schema.StringAttribute{
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+				Validators:    []validator.String{resourceIdValidator{r.service.targetType}},
+				Required:      true,
+				Description:   r.service.targetDescription,
+			},
+			"ip_rules": schema.ListAttribute{
+				ElementType: types.StringType,
+				Validators:  []validator.List{ipRangeValidator{}},
+				Required:    true,
+				Description: "List of IP addresses or CIDR ranges to allow access to the " + r.service.displayName + ".",
+			},
+		},
+	}
+}
+
+func (r *ipRuleFilterResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if shouldCheckPermissions(r.client, req) {
+		checkPermissions(ctx, r.client, req.Plan, r.service.targetAttribute, r.service.targetType, r.service.requiredActions, &resp.Diagnostics)
+	}
+}
+
+func (r *ipRuleFilterResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state ipRuleFilterResourceModel
+	resp.Diagnostics.Append(r.getModel(ctx, req.State, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	adapter := r.service.newAdapter(r.client, state.TargetId.ValueString())
+	currentIpRules, found := r.readIpRules(ctx, adapter, state.TargetId.ValueString(), &resp.Diagnostics)
+	if !found {
+		resp.Diagnostics.AddWarning(r.service.displayName+" not found",
+			r.service.displayName+" "+state.TargetId.ValueString()+" was deleted outside of Terraform, it's removed from the state.")
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	newIpRulesState, diags := types.ListValueFrom(ctx, types.StringType, additive.Filter[string](adapter, listToStrings(state.IpRules), currentIpRules))
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+	state.IpRules = newIpRulesState
+	state.ID = types.StringValue(adapter.resourceID())
+	resp.Diagnostics.Append(r.setModel(ctx, &resp.State, &state)...)
+}
+
+func (r *ipRuleFilterResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan ipRuleFilterResourceModel
+	resp.Diagnostics.Append(r.getModel(ctx, req.Plan, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.upsert(ctx, nil, &plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(r.setModel(ctx, &resp.State, &plan)...)
+}
+
+func (r *ipRuleFilterResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan ipRuleFilterResourceModel
+	resp.Diagnostics.Append(r.getModel(ctx, req.Plan, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var state ipRuleFilterResourceModel
+	resp.Diagnostics.Append(r.getModel(ctx, req.State, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.upsert(ctx, &state, &plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(r.setModel(ctx, &resp.State, &plan)...)
+}
+
+// Delete is a no-op for the same reasons as CosmosDBIpFilterResource.Delete.
+func (r *ipRuleFilterResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+}
+
+// readIpRules reads the current IP rules through the adapter and checks they can be managed. found is false, with no
+// diagnostic, when the target doesn't exist.
+func (r *ipRuleFilterResource) readIpRules(ctx context.Context, adapter ipRuleAdapter, targetId string, diags *diag.Diagnostics) (_ []string, found bool) {
+	currentIpRules, err := adapter.Read(ctx)
+	var notFound *client.NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, false
+	}
+	if err != nil {
+		addClientError(
+			diags,
+			"Could not read "+r.service.displayName,
+			"Failed to read "+r.service.displayName+" with ID "+targetId,
+			err,
+		)
+		return nil, true
+	}
+	if !adapter.publicNetworkAccess() {
+		diags.AddError(
+			r.service.displayName+" is not publicly accessible",
+			r.service.displayName+" "+targetId+" is not publicly accessible. Please enable public network access to add IP rules.",
+		)
+		return nil, true
+	}
+	return currentIpRules, true
+}
+
+// This method modifies plan and diags inplace
+func (r *ipRuleFilterResource) upsert(ctx context.Context, state, plan *ipRuleFilterResourceModel, diags *diag.Diagnostics) {
+	adapter := r.service.newAdapter(r.client, plan.TargetId.ValueString())
+	currentIpRules, found := r.readIpRules(ctx, adapter, plan.TargetId.ValueString(), diags)
+	if !found {
+		diags.AddError(r.service.displayName+" not found", r.service.displayName+" "+plan.TargetId.ValueString()+" doesn't exist.")
+		return
+	}
+	if diags.HasError() {
+		return
+	}
+	plan.ID = types.StringValue(adapter.resourceID())
+
+	var previous []string
+	if state != nil {
+		previous = listToStrings(state.IpRules)
+	}
+	merge := additive.Merge[string](adapter, currentIpRules, previous, listToStrings(plan.IpRules))
+	if !merge.Changed() {
+		return
+	}
+
+	tflog.Info(ctx, fmt.Sprintf("IP Rules to add: %v", merge.Added))
+	tflog.Info(ctx, fmt.Sprintf("IP Rules to remove: %v", merge.Removed))
+	err := additive.Commit[string](ctx, adapter, merge)
+	tflog.Info(ctx, "Finished updating IP Rules")
+	if err != nil {
+		addClientError(
+			diags,
+			"Could not update "+r.service.displayName+" IP rules",
+			"Failed to update the IP rules of "+r.service.displayName+" "+plan.TargetId.ValueString(),
+			err,
+		)
+	}
+}
+
+func listToStrings(list types.List) []string {
+	values := make([]string, 0, len(list.Elements()))
+	for _, element := range list.Elements() {
+		values = append(values, element.(types.String).ValueString())
+	}
+	return values
+}
+
+func stringsToList(ctx context.Context, values []string, diags *diag.Diagnostics) types.List {
+	list, d := types.ListValueFrom(ctx, types.StringType, values)
+	diags.Append(d...)
+	return list
+}
+
+// allKnown reports whether every element of a known list is known, e.g. not an attribute of a resource to create.
+func allKnown(list types.List) bool {
+	for _, element := range list.Elements() {
+		if element.IsUnknown() {
+			return false
+		}
+	}
+	return true
+}
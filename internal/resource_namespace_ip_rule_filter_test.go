@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"terraform-provider-azurermext/internal/testing/fakearm"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+)
+
+func TestAccEventHubNamespaceIpRuleFilter_basic(t *testing.T) {
+	testAccNamespaceIpRuleFilterBasic(t, "azurermext_eventhub_namespace_ip_rule_filter", "eventhub_namespace_id", "Microsoft.EventHub/namespaces")
+}
+
+func TestAccServiceBusNamespaceIpRuleFilter_basic(t *testing.T) {
+	testAccNamespaceIpRuleFilterBasic(t, "azurermext_servicebus_namespace_ip_rule_filter", "servicebus_namespace_id", "Microsoft.ServiceBus/namespaces")
+}
+
+// testAccNamespaceIpRuleFilterBasic creates and updates a filter of a namespace having foreign rules, a virtual
+// network rule and trusted service access, which must all survive the PUT of the rule set.
+func testAccNamespaceIpRuleFilterBasic(t *testing.T, resourceType, attribute, namespaceType string) {
+	server := fakearm.New(t)
+	namespaceId := "/subscriptions/" + testSubscriptionId + "/resourceGroups/rg/providers/" + namespaceType + "/basic"
+	testAddNamespace(server, namespaceId)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + testNamespaceIpRuleFilterConfig(resourceType, attribute, namespaceId, `"10.0.0.1"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckNamespaceIpRules(server, namespaceId, "1.1.1.1", "10.0.0.1"),
+					resource.TestCheckResourceAttr(resourceType+".test", "id", namespaceId),
+					resource.TestCheckResourceAttr(resourceType+".test", "ip_rules.#", "1"),
+				),
+			},
+			{
+				Config: server.ProviderConfig() + testNamespaceIpRuleFilterConfig(resourceType, attribute, namespaceId, `"10.0.0.2"`),
+				Check:  testCheckNamespaceIpRules(server, namespaceId, "1.1.1.1", "10.0.0.2"),
+			},
+		},
+		// Destroying the resource leaves the namespace untouched.
+		CheckDestroy: testCheckNamespaceIpRules(server, namespaceId, "1.1.1.1", "10.0.0.2"),
+	})
+}
+
+func TestAccEventHubNamespaceIpRuleFilter_deleted(t *testing.T) {
+	server := fakearm.New(t)
+	namespaceId := "/subscriptions/" + testSubscriptionId + "/resourceGroups/rg/providers/Microsoft.EventHub/namespaces/deleted"
+	testAddNamespace(server, namespaceId)
+	config := server.ProviderConfig() + testNamespaceIpRuleFilterConfig("azurermext_eventhub_namespace_ip_rule_filter", "eventhub_namespace_id", namespaceId, `"10.0.0.1"`)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories(server),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check:  testCheckNamespaceIpRules(server, namespaceId, "1.1.1.1", "10.0.0.1"),
+			},
+			{
+				// The refresh drops the filter of a deleted namespace from the state instead of failing.
+				PreConfig:          func() { server.RemoveNamespace(namespaceId) },
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+// testSubnetId is the subnet of the virtual network rules of the fake resources.
+const testSubnetId = "/subscriptions/" + testSubscriptionId + "/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/default"
+
+// testAddNamespace adds a namespace with the foreign rule 1.1.1.1 and a virtual network rule.
+func testAddNamespace(server *fakearm.Server, namespaceId string) {
+	server.AddNamespace(namespaceId, []string{"1.1.1.1"})
+	server.UpdateNamespace(namespaceId, func(namespace *fakearm.Namespace) {
+		namespace.VirtualNetworkRules = []string{testSubnetId}
+	})
+}
+
+func testNamespaceIpRuleFilterConfig(resourceType, attribute, namespaceId, ipRules string) string {
+	return fmt.Sprintf(`
+resource %q "test" {
+  %s = %q
+  ip_rules = [%s]
+}
+`, resourceType, attribute, namespaceId, ipRules)
+}
+
+// testCheckNamespaceIpRules checks the IP rules of the rule set of a fake namespace, in order, and that its other
+// properties, as set by testAddNamespace, were kept.
+func testCheckNamespaceIpRules(server *fakearm.Server, namespaceId string, want ...string) func(*terraform.State) error {
+	return func(*terraform.State) error {
+		namespace, ok := server.Namespace(namespaceId)
+		if !ok {
+			return fmt.Errorf("namespace %s not found", namespaceId)
+		}
+		if !slices.Equal(namespace.IpRules, want) {
+			return fmt.Errorf("namespace %s has IP rules %v, want %v", namespaceId, namespace.IpRules, want)
+		}
+		if namespace.DefaultAction != "Deny" || !namespace.TrustedServiceAccessEnabled || !namespace.PublicNetworkAccess {
+			return fmt.Errorf("namespace %s has default action %s, trusted service access %t and public network access %t, want Deny, true and true",
+				namespaceId, namespace.DefaultAction, namespace.TrustedServiceAccessEnabled, namespace.PublicNetworkAccess)
+		}
+		if !slices.Equal(namespace.VirtualNetworkRules, []string{testSubnetId}) {
+			return fmt.Errorf("namespace %s has virtual network rules %v, want %s", namespaceId, namespace.VirtualNetworkRules, testSubnetId)
+		}
+		return nil
+	}
+}
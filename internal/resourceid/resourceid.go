@@ -0,0 +1,187 @@
+// Package resourceid parses and validates Azure Resource Manager resource IDs.
+//
+// ARM treats the keywords (subscriptions, resourceGroups, providers), provider namespaces and resource types as
+// case-insensitive, so every comparison in this package is case-insensitive as well. Names are kept as written.
+package resourceid
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	subscriptionIdRegexp  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	resourceGroupRegexp   = regexp.MustCompile(`^[-\w._()]{1,90}$`)
+	providerNamespaceRegx = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(\.[A-Za-z][A-Za-z0-9]*)+$`)
+)
+
+// ID is a parsed ARM resource ID such as
+// /subscriptions/{sub}/resourceGroups/{rg}/providers/Microsoft.DocumentDB/databaseAccounts/{name}.
+//
+// Subscription and resource group scoped IDs are supported too, in which case the fields describing deeper
+// levels are left empty.
+type ID struct {
+	SubscriptionID string
+	ResourceGroup  string
+	// Provider is the resource provider namespace, e.g. Microsoft.DocumentDB.
+	Provider string
+	// Types and Names hold the resource type segments and their names, outermost first.
+	// For a nested resource such as .../namespaces/ns/networkRuleSets/default, Types is
+	// [namespaces networkRuleSets] and Names is [ns default].
+	Types []string
+	Names []string
+}
+
+// ParseError describes why a string isn't a valid resource ID.
+type ParseError struct {
+	ID     string
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid resource ID %q: %s", e.ID, e.Reason)
+}
+
+// Parse parses a resource ID of the form
+// /subscriptions/{sub}[/resourceGroups/{rg}[/providers/{namespace}/{type}/{name}[/{type}/{name}...]]].
+func Parse(id string) (ID, error) {
+	fail := func(format string, args ...any) (ID, error) {
+		return ID{}, &ParseError{ID: id, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	if !strings.HasPrefix(id, "/") {
+		return fail("it must start with a slash")
+	}
+	segments := strings.Split(strings.TrimPrefix(id, "/"), "/")
+	for _, segment := range segments {
+		if segment == "" {
+			return fail("it must not contain empty segments")
+		}
+	}
+
+	parsed := ID{}
+	if !strings.EqualFold(segments[0], "subscriptions") || len(segments) < 2 {
+		return fail("it must start with /subscriptions/{subscriptionId}")
+	}
+	if !subscriptionIdRegexp.MatchString(segments[1]) {
+		return fail("subscription ID %q is not a UUID", segments[1])
+	}
+	parsed.SubscriptionID = segments[1]
+	segments = segments[2:]
+	if len(segments) == 0 {
+		return parsed, nil
+	}
+
+	if !strings.EqualFold(segments[0], "resourceGroups") || len(segments) < 2 {
+		return fail("expected /resourceGroups/{resourceGroupName} after the subscription")
+	}
+	if !resourceGroupRegexp.MatchString(segments[1]) || strings.HasSuffix(segments[1], ".") {
+		return fail("resource group name %q is not valid", segments[1])
+	}
+	parsed.ResourceGroup = segments[1]
+	segments = segments[2:]
+	if len(segments) == 0 {
+		return parsed, nil
+	}
+
+	if !strings.EqualFold(segments[0], "providers") || len(segments) < 2 {
+		return fail("expected /providers/{namespace} after the resource group")
+	}
+	if !providerNamespaceRegx.MatchString(segments[1]) {
+		return fail("provider namespace %q is not valid", segments[1])
+	}
+	parsed.Provider = segments[1]
+	segments = segments[2:]
+	if len(segments) == 0 || len(segments)%2 != 0 {
+		return fail("expected {type}/{name} pairs after the provider namespace")
+	}
+	for i := 0; i < len(segments); i += 2 {
+		parsed.Types = append(parsed.Types, segments[i])
+		parsed.Names = append(parsed.Names, segments[i+1])
+	}
+	return parsed, nil
+}
+
+// ParseAs parses a resource ID and checks it has the expected resource type, e.g. Microsoft.DocumentDB/databaseAccounts.
+func ParseAs(id, resourceType string) (ID, error) {
+	parsed, err := Parse(id)
+	if err != nil {
+		return ID{}, err
+	}
+	if !parsed.IsType(resourceType) {
+		actual := parsed.ResourceType()
+		if actual == "" {
+			actual = "a subscription or resource group"
+		}
+		return ID{}, &ParseError{ID: id, Reason: fmt.Sprintf("expected a %s ID, got %s", resourceType, actual)}
+	}
+	return parsed, nil
+}
+
+// String formats the ID back to its canonical form.
+func (id ID) String() string {
+	var b strings.Builder
+	b.WriteString("/subscriptions/" + id.SubscriptionID)
+	if id.ResourceGroup != "" {
+		b.WriteString("/resourceGroups/" + id.ResourceGroup)
+	}
+	if id.Provider != "" {
+		b.WriteString("/providers/" + id.Provider)
+	}
+	for i := range id.Types {
+		b.WriteString("/" + id.Types[i] + "/" + id.Names[i])
+	}
+	return b.String()
+}
+
+// ResourceType returns the full resource type, e.g. Microsoft.EventHub/namespaces/networkRuleSets.
+// It's empty for subscription and resource group IDs.
+func (id ID) ResourceType() string {
+	if id.Provider == "" {
+		return ""
+	}
+	return id.Provider + "/" + strings.Join(id.Types, "/")
+}
+
+// IsType reports whether the ID has the given resource type, ignoring case.
+func (id ID) IsType(resourceType string) bool {
+	return id.Provider != "" && strings.EqualFold(id.ResourceType(), resourceType)
+}
+
+// Equal reports whether two IDs point to the same resource, ignoring the case of every segment like ARM does.
+func (id ID) Equal(other ID) bool {
+	return strings.EqualFold(id.String(), other.String())
+}
+
+// Name returns the name of the innermost resource, or the resource group name for resource group IDs.
+func (id ID) Name() string {
+	if len(id.Names) == 0 {
+		return id.ResourceGroup
+	}
+	return id.Names[len(id.Names)-1]
+}
+
+// Parent returns the ID of the enclosing resource: the parent resource for nested resources,
+// otherwise the resource group, then the subscription.
+func (id ID) Parent() ID {
+	parent := id
+	switch {
+	case len(id.Types) > 1:
+		parent.Types = id.Types[:len(id.Types)-1]
+		parent.Names = id.Names[:len(id.Names)-1]
+	case len(id.Types) == 1:
+		parent.Provider, parent.Types, parent.Names = "", nil, nil
+	default:
+		parent.ResourceGroup = ""
+	}
+	return parent
+}
+
+// Child returns the ID of a child resource, e.g. id.Child("networkRuleSets", "default").
+func (id ID) Child(resourceType, name string) ID {
+	child := id
+	child.Types = append(append([]string{}, id.Types...), resourceType)
+	child.Names = append(append([]string{}, id.Names...), name)
+	return child
+}
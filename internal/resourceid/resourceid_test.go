@@ -0,0 +1,276 @@
+package resourceid
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	testSubscription = "00000000-0000-0000-0000-000000000001"
+	testAccount      = "/subscriptions/" + testSubscription + "/resourceGroups/rg/providers/Microsoft.DocumentDB/databaseAccounts/account"
+	testRuleSet      = "/subscriptions/" + testSubscription + "/resourceGroups/rg/providers/Microsoft.EventHub/namespaces/ns/networkRuleSets/default"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want ID
+		// wantErr is a part of the expected error reason, empty when the ID is valid.
+		wantErr string
+	}{
+		{
+			name: "subscription",
+			id:   "/subscriptions/" + testSubscription,
+			want: ID{SubscriptionID: testSubscription},
+		},
+		{
+			name: "resource group",
+			id:   "/subscriptions/" + testSubscription + "/resourceGroups/my-rg_(1).2",
+			want: ID{SubscriptionID: testSubscription, ResourceGroup: "my-rg_(1).2"},
+		},
+		{
+			name: "resource",
+			id:   testAccount,
+			want: ID{SubscriptionID: testSubscription, ResourceGroup: "rg", Provider: "Microsoft.DocumentDB", Types: []string{"databaseAccounts"}, Names: []string{"account"}},
+		},
+		{
+			name: "nested resource",
+			id:   testRuleSet,
+			want: ID{SubscriptionID: testSubscription, ResourceGroup: "rg", Provider: "Microsoft.EventHub", Types: []string{"namespaces", "networkRuleSets"}, Names: []string{"ns", "default"}},
+		},
+		{
+			name: "case-insensitive keywords",
+			id:   "/SUBSCRIPTIONS/" + testSubscription + "/resourcegroups/RG/PROVIDERS/microsoft.documentdb/DATABASEACCOUNTS/Account",
+			want: ID{SubscriptionID: testSubscription, ResourceGroup: "RG", Provider: "microsoft.documentdb", Types: []string{"DATABASEACCOUNTS"}, Names: []string{"Account"}},
+		},
+		{
+			name:    "no leading slash",
+			id:      strings.TrimPrefix(testAccount, "/"),
+			wantErr: "must start with a slash",
+		},
+		{
+			name:    "trailing slash",
+			id:      testAccount + "/",
+			wantErr: "empty segments",
+		},
+		{
+			name:    "duplicate slash",
+			id:      strings.Replace(testAccount, "/resourceGroups", "//resourceGroups", 1),
+			wantErr: "empty segments",
+		},
+		{
+			name:    "empty",
+			id:      "",
+			wantErr: "must start with a slash",
+		},
+		{
+			name:    "no subscription ID",
+			id:      "/subscriptions",
+			wantErr: "must start with /subscriptions/{subscriptionId}",
+		},
+		{
+			name:    "subscription ID not a UUID",
+			id:      "/subscriptions/sub",
+			wantErr: `subscription ID "sub" is not a UUID`,
+		},
+		{
+			name:    "no resource group name",
+			id:      "/subscriptions/" + testSubscription + "/resourceGroups",
+			wantErr: "expected /resourceGroups/{resourceGroupName}",
+		},
+		{
+			name:    "resource group name ending with a dot",
+			id:      "/subscriptions/" + testSubscription + "/resourceGroups/rg.",
+			wantErr: `resource group name "rg." is not valid`,
+		},
+		{
+			name:    "no provider namespace",
+			id:      "/subscriptions/" + testSubscription + "/resourceGroups/rg/providers",
+			wantErr: "expected /providers/{namespace}",
+		},
+		{
+			name:    "invalid provider namespace",
+			id:      "/subscriptions/" + testSubscription + "/resourceGroups/rg/providers/DocumentDB/databaseAccounts/account",
+			wantErr: `provider namespace "DocumentDB" is not valid`,
+		},
+		{
+			name:    "no resource type",
+			id:      "/subscriptions/" + testSubscription + "/resourceGroups/rg/providers/Microsoft.DocumentDB",
+			wantErr: "{type}/{name} pairs",
+		},
+		{
+			name:    "odd segment count",
+			id:      testAccount + "/sqlDatabases",
+			wantErr: "{type}/{name} pairs",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.id)
+			if tt.wantErr != "" {
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) || parseErr.ID != tt.id || !strings.Contains(parseErr.Reason, tt.wantErr) {
+					t.Fatalf("Parse() error = %v, want a ParseError containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %#v, want %#v", got, tt.want)
+			}
+			// The keywords are written back in their canonical case.
+			if !strings.EqualFold(got.String(), tt.id) {
+				t.Errorf("String() = %q, want %q", got.String(), tt.id)
+			}
+		})
+	}
+}
+
+func TestParseAs(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           string
+		resourceType string
+		wantErr      string
+	}{
+		{name: "same type", id: testAccount, resourceType: "Microsoft.DocumentDB/databaseAccounts"},
+		{name: "type case", id: testAccount, resourceType: "microsoft.documentdb/DatabaseAccounts"},
+		{name: "nested type", id: testRuleSet, resourceType: "Microsoft.EventHub/namespaces/networkRuleSets"},
+		{
+			name:         "other type",
+			id:           testAccount,
+			resourceType: "Microsoft.ContainerRegistry/registries",
+			wantErr:      "expected a Microsoft.ContainerRegistry/registries ID, got Microsoft.DocumentDB/databaseAccounts",
+		},
+		{
+			name:         "parent type",
+			id:           testRuleSet,
+			resourceType: "Microsoft.EventHub/namespaces",
+			wantErr:      "got Microsoft.EventHub/namespaces/networkRuleSets",
+		},
+		{
+			name:         "resource group",
+			id:           "/subscriptions/" + testSubscription + "/resourceGroups/rg",
+			resourceType: "Microsoft.DocumentDB/databaseAccounts",
+			wantErr:      "got a subscription or resource group",
+		},
+		{
+			name:         "invalid ID",
+			id:           testAccount + "/",
+			resourceType: "Microsoft.DocumentDB/databaseAccounts",
+			wantErr:      "empty segments",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAs(tt.id, tt.resourceType)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseAs() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAs() failed: %v", err)
+			}
+			if got.String() != tt.id {
+				t.Errorf("ParseAs() = %q, want %q", got, tt.id)
+			}
+		})
+	}
+}
+
+func TestParent(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want string
+	}{
+		{name: "nested resource", id: testRuleSet, want: "/subscriptions/" + testSubscription + "/resourceGroups/rg/providers/Microsoft.EventHub/namespaces/ns"},
+		{name: "resource", id: testAccount, want: "/subscriptions/" + testSubscription + "/resourceGroups/rg"},
+		{name: "resource group", id: "/subscriptions/" + testSubscription + "/resourceGroups/rg", want: "/subscriptions/" + testSubscription},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := Parse(tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := id.Parent().String(); got != tt.want {
+				t.Errorf("Parent() = %q, want %q", got, tt.want)
+			}
+			if got := id.String(); got != tt.id {
+				t.Errorf("Parent() modified the ID to %q", got)
+			}
+		})
+	}
+}
+
+func TestChild(t *testing.T) {
+	namespace, err := Parse("/subscriptions/" + testSubscription + "/resourceGroups/rg/providers/Microsoft.EventHub/namespaces/ns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := namespace.Child("networkRuleSets", "default")
+	if got := child.String(); got != testRuleSet {
+		t.Errorf("Child() = %q, want %q", got, testRuleSet)
+	}
+	if !child.IsType("Microsoft.EventHub/namespaces/networkRuleSets") || child.Name() != "default" {
+		t.Errorf("Child() has type %q and name %q", child.ResourceType(), child.Name())
+	}
+	if !child.Parent().Equal(namespace) {
+		t.Errorf("Child().Parent() = %q, want %q", child.Parent(), namespace)
+	}
+
+	// Children of the same parent don't share their segments.
+	other := namespace.Child("authorizationRules", "root")
+	if got := child.String(); got != testRuleSet {
+		t.Errorf("a second Child() modified the first to %q", got)
+	}
+	if other.Name() != "root" || len(namespace.Types) != 1 {
+		t.Errorf("Child() = %q from %q", other, namespace)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{name: "same", a: testAccount, b: testAccount, want: true},
+		{name: "case", a: testAccount, b: strings.ToUpper(testAccount), want: true},
+		{
+			name: "keyword case",
+			a:    testAccount,
+			b:    "/SUBSCRIPTIONS/" + testSubscription + "/RESOURCEGROUPS/rg/PROVIDERS/Microsoft.DocumentDB/databaseAccounts/account",
+			want: true,
+		},
+		{name: "other name", a: testAccount, b: strings.TrimSuffix(testAccount, "account") + "other"},
+		{name: "parent", a: testRuleSet, b: strings.TrimSuffix(testRuleSet, "/networkRuleSets/default")},
+		{name: "other scope", a: "/subscriptions/" + testSubscription, b: "/subscriptions/" + testSubscription + "/resourceGroups/rg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := Parse(tt.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := Parse(tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := a.Equal(b); got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+			if got := b.Equal(a); got != tt.want {
+				t.Errorf("Equal() isn't symmetric: %v", got)
+			}
+		})
+	}
+}
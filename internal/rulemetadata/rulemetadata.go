@@ -0,0 +1,148 @@
+// Package rulemetadata stores metadata of firewall rules, such as their description, in the tags of the Azure
+// resource they belong to, since the firewalls have no field for it.
+//
+// The metadata is a JSON object keyed by rule, compressed with DEFLATE and base64url encoded. Azure limits tag
+// values to 256 characters, so a longer encoding is split over the tags "{key}", "{key}.1", "{key}.2"... up to
+// MaxTags tags.
+package rulemetadata
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxTags bounds the number of tags the metadata may take, out of the 50 tags a resource accepts.
+	MaxTags = 8
+	// maxTagValueLength is the maximum length of a tag value accepted by Azure.
+	maxTagValueLength = 256
+)
+
+// Metadata describes a rule.
+type Metadata struct {
+	Description string `json:"d,omitempty"`
+	Owner       string `json:"o,omitempty"`
+}
+
+// IsZero reports whether the metadata holds nothing, in which case it isn't stored.
+func (m Metadata) IsZero() bool {
+	return m == Metadata{}
+}
+
+// TooLargeError is returned by Encode when the metadata doesn't fit in MaxTags tags.
+type TooLargeError struct {
+	Tags int
+}
+
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf("the rule metadata needs %d tags once compressed, at most %d are allowed", e.Tags, MaxTags)
+}
+
+// Encode returns the tags holding rules under key. Rules without metadata are left out and an empty map is
+// returned when none is left.
+func Encode(key string, rules map[string]Metadata) (map[string]string, error) {
+	stored := map[string]Metadata{}
+	for rule, metadata := range rules {
+		if !metadata.IsZero() {
+			stored[rule] = metadata
+		}
+	}
+	tags := map[string]string{}
+	if len(stored) == 0 {
+		return tags, nil
+	}
+	// Map keys are sorted by encoding/json, the encoding only changes with the metadata.
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return nil, err
+	}
+	var compressed bytes.Buffer
+	writer, err := flate.NewWriter(&compressed, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(compressed.Bytes())
+
+	count := (len(encoded) + maxTagValueLength - 1) / maxTagValueLength
+	if count > MaxTags {
+		return nil, &TooLargeError{Tags: count}
+	}
+	for i := 0; i < count; i++ {
+		tags[tagName(key, i)] = encoded[i*maxTagValueLength : min((i+1)*maxTagValueLength, len(encoded))]
+	}
+	return tags, nil
+}
+
+// Decode returns the rules stored under key in tags. Missing tags decode to an empty map.
+func Decode(key string, tags map[string]string) (map[string]Metadata, error) {
+	var encoded strings.Builder
+	for i := 0; i < MaxTags; i++ {
+		value, ok := lookup(tags, tagName(key, i))
+		if !ok {
+			break
+		}
+		encoded.WriteString(value)
+	}
+	rules := map[string]Metadata{}
+	if encoded.Len() == 0 {
+		return rules, nil
+	}
+	compressed, err := base64.RawURLEncoding.DecodeString(encoded.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule metadata in tag %s: %w", key, err)
+	}
+	data, err := io.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule metadata in tag %s: %w", key, err)
+	}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid rule metadata in tag %s: %w", key, err)
+	}
+	return rules, nil
+}
+
+// TagNames returns the names of the tags holding metadata under key, as written in tags.
+func TagNames(key string, tags map[string]string) []string {
+	var names []string
+	for name := range tags {
+		for i := 0; i < MaxTags; i++ {
+			if strings.EqualFold(name, tagName(key, i)) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+func tagName(key string, i int) string {
+	if i == 0 {
+		return key
+	}
+	return key + "." + strconv.Itoa(i)
+}
+
+// lookup finds a tag ignoring case, like Azure compares tag names.
+func lookup(tags map[string]string, name string) (string, bool) {
+	if value, ok := tags[name]; ok {
+		return value, true
+	}
+	for tagName, value := range tags {
+		if strings.EqualFold(tagName, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,116 @@
+// Package servicetags reads Azure service tags, e.g. `AzureCloud.westeurope` or `DataFactory`, and the IP ranges
+// they stand for.
+//
+// Both the ServiceTags_Public JSON files Microsoft publishes weekly and the answer of the ARM `serviceTags` API
+// share the same format:
+//
+//	{
+//	  "changeNumber": 295,
+//	  "cloud": "Public",
+//	  "values": [
+//	    {"name": "DataFactory", "id": "DataFactory", "properties": {"addressPrefixes": ["4.145.74.52/30", ...]}}
+//	  ]
+//	}
+package servicetags
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// ServiceTags is a ServiceTags_Public file or a response of the ARM serviceTags API.
+type ServiceTags struct {
+	// ChangeNumber is incremented whenever a tag changes. The files encode it as a number, the API as a string.
+	ChangeNumber json.Number  `json:"changeNumber"`
+	Cloud        string       `json:"cloud"`
+	Values       []ServiceTag `json:"values"`
+}
+
+type ServiceTag struct {
+	Name       string               `json:"name"`
+	ID         string               `json:"id"`
+	Properties ServiceTagProperties `json:"properties"`
+}
+
+type ServiceTagProperties struct {
+	ChangeNumber    json.Number `json:"changeNumber"`
+	Region          string      `json:"region"`
+	SystemService   string      `json:"systemService"`
+	AddressPrefixes []string    `json:"addressPrefixes"`
+}
+
+// UnknownTagsError lists the requested tags missing from the service tags.
+type UnknownTagsError struct {
+	Names []string
+}
+
+func (e *UnknownTagsError) Error() string {
+	return "unknown service tags: " + strings.Join(e.Names, ", ")
+}
+
+// Parse decodes service tags in the ServiceTags_Public format.
+func Parse(data []byte) (*ServiceTags, error) {
+	var tags ServiceTags
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, fmt.Errorf("invalid service tags: %w", err)
+	}
+	if len(tags.Values) == 0 {
+		return nil, errors.New("invalid service tags: no tag in values")
+	}
+	return &tags, nil
+}
+
+// ReadFile parses a ServiceTags_Public file, as downloaded from the Microsoft Download Center.
+func ReadFile(path string) (*ServiceTags, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return tags, nil
+}
+
+// Find returns the tag with the given name or ID. Names are case-insensitive.
+func (s *ServiceTags) Find(name string) (ServiceTag, bool) {
+	for _, tag := range s.Values {
+		if strings.EqualFold(tag.Name, name) || strings.EqualFold(tag.ID, name) {
+			return tag, true
+		}
+	}
+	return ServiceTag{}, false
+}
+
+// IPv4Prefixes returns the IPv4 prefixes of the named tags, in the order of the tags, duplicates included.
+// IPv6 prefixes are left out since the Azure firewalls this provider manages only accept IPv4 rules.
+// An *UnknownTagsError is returned when any of the tags doesn't exist.
+func (s *ServiceTags) IPv4Prefixes(names ...string) ([]string, error) {
+	prefixes := []string{}
+	var unknown []string
+	for _, name := range names {
+		tag, ok := s.Find(name)
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		for _, value := range tag.Properties.AddressPrefixes {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("service tag %s: invalid address prefix %q: %w", tag.Name, value, err)
+			}
+			if prefix.Addr().Is4() {
+				prefixes = append(prefixes, value)
+			}
+		}
+	}
+	if len(unknown) != 0 {
+		return nil, &UnknownTagsError{Names: unknown}
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,139 @@
+package servicetags
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testServiceTags = `{
+  "changeNumber": 295,
+  "cloud": "Public",
+  "values": [
+    {
+      "name": "DataFactory.WestEurope",
+      "id": "DataFactory.WestEurope",
+      "properties": {"changeNumber": 12, "region": "westeurope", "addressPrefixes": ["4.175.0.0/26", "2603:1020:206::/122", "20.38.80.0/27"]}
+    },
+    {
+      "name": "AzureCloud",
+      "id": "AzureCloud",
+      "properties": {"changeNumber": "7", "addressPrefixes": ["20.38.80.0/27", "40.64.0.0/10"]}
+    },
+    {
+      "name": "IPv6Only",
+      "id": "IPv6Only",
+      "properties": {"addressPrefixes": ["2603:1000::/24"]}
+    }
+  ]
+}`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name             string
+		data             string
+		wantChangeNumber string
+		wantErr          string
+	}{
+		{name: "numeric change number", data: testServiceTags, wantChangeNumber: "295"},
+		{name: "string change number", data: strings.Replace(testServiceTags, "295", `"295"`, 1), wantChangeNumber: "295"},
+		{name: "no change number", data: `{"values": [{"name": "A", "properties": {}}]}`, wantChangeNumber: ""},
+		{name: "invalid change number", data: `{"changeNumber": "latest", "values": [{"name": "A"}]}`, wantErr: "invalid service tags"},
+		{name: "invalid JSON", data: `{"values": [`, wantErr: "invalid service tags"},
+		{name: "no values", data: `{"changeNumber": 1, "values": []}`, wantErr: "no tag in values"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := Parse([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() failed: %v", err)
+			}
+			if got := tags.ChangeNumber.String(); got != tt.wantChangeNumber {
+				t.Errorf("ChangeNumber = %q, want %q", got, tt.wantChangeNumber)
+			}
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "ServiceTags_Public.json")
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(valid, []byte(testServiceTags), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(invalid, []byte("<html>"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if tags, err := ReadFile(valid); err != nil || len(tags.Values) != 3 {
+		t.Errorf("ReadFile() = %v, %v, want 3 tags", tags, err)
+	}
+	if _, err := ReadFile(invalid); err == nil || !strings.HasPrefix(err.Error(), invalid+": invalid service tags") {
+		t.Errorf("ReadFile() error = %v, want it to name the file", err)
+	}
+	if _, err := ReadFile(filepath.Join(dir, "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadFile() error = %v, want %v", err, os.ErrNotExist)
+	}
+}
+
+func TestIPv4Prefixes(t *testing.T) {
+	tags, err := Parse([]byte(testServiceTags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		names       []string
+		want        []string
+		wantUnknown []string
+	}{
+		{name: "IPv6 left out", names: []string{"DataFactory.WestEurope"}, want: []string{"4.175.0.0/26", "20.38.80.0/27"}},
+		{name: "case-insensitive", names: []string{"azurecloud"}, want: []string{"20.38.80.0/27", "40.64.0.0/10"}},
+		{
+			name:  "several tags with duplicates",
+			names: []string{"AzureCloud", "DataFactory.WestEurope"},
+			want:  []string{"20.38.80.0/27", "40.64.0.0/10", "4.175.0.0/26", "20.38.80.0/27"},
+		},
+		{name: "IPv6 only", names: []string{"IPv6Only"}, want: []string{}},
+		{name: "none", names: nil, want: []string{}},
+		{name: "unknown tags", names: []string{"DataFactory", "AzureCloud", "Storage"}, wantUnknown: []string{"DataFactory", "Storage"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tags.IPv4Prefixes(tt.names...)
+			if tt.wantUnknown != nil {
+				var unknownErr *UnknownTagsError
+				if !errors.As(err, &unknownErr) || !slices.Equal(unknownErr.Names, tt.wantUnknown) {
+					t.Fatalf("IPv4Prefixes() error = %v, want the unknown tags %v", err, tt.wantUnknown)
+				}
+				if want := "unknown service tags: " + strings.Join(tt.wantUnknown, ", "); err.Error() != want {
+					t.Errorf("Error() = %q, want %q", err.Error(), want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("IPv4Prefixes() failed: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("IPv4Prefixes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid prefix", func(t *testing.T) {
+		invalid := &ServiceTags{Values: []ServiceTag{{Name: "Broken", Properties: ServiceTagProperties{AddressPrefixes: []string{"4.175.0.0"}}}}}
+		if _, err := invalid.IPv4Prefixes("Broken"); err == nil || !strings.Contains(err.Error(), `service tag Broken: invalid address prefix "4.175.0.0"`) {
+			t.Errorf("IPv4Prefixes() error = %v, want an invalid prefix error", err)
+		}
+	})
+}
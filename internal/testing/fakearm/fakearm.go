@@ -0,0 +1,927 @@
+// Package fakearm is an in-process fake of the Azure endpoints used by the provider, so acceptance tests can run
+// `resource.Test` without a subscription.
+//
+// It emulates the AAD client credentials token endpoint, CosmosDB account and Container Registry GET/PATCH and the
+// GET/PUT of the network rule set of Event Hubs and Service Bus namespaces, including the `Azure-AsyncOperation` polling of updates, the listing of accounts and permissions, paginated on demand, the
+// serviceTags and tags APIs, canned Resource Graph results and the rejection of tokens issued by another tenant than
+// the one of the subscription. Faults (throttling, failed operations, 404s, slow updates, slow or failing token requests) can be injected to exercise
+// the provider's error handling.
+//
+//	server := fakearm.New(t)
+//	server.AddCosmosDBAccount(accountId, []string{"10.0.0.1"})
+//	resource.Test(t, resource.TestCase{
+//		ProtoV6ProviderFactories: map[string]func() (tfprotov6.ProviderServer, error){
+//			"azurermext": providerserver.NewProtocol6WithError(internal.NewProviderWithClientOptions(server.ClientOptions()...)()),
+//		},
+//		Steps: []resource.TestStep{{Config: server.ProviderConfig() + config}},
+//	})
+package fakearm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"terraform-provider-azurermext/internal/client"
+	"terraform-provider-azurermext/internal/servicetags"
+	"testing"
+	"time"
+)
+
+// Credentials accepted by the fake token endpoint.
+const (
+	TenantID     = "00000000-0000-0000-0000-00000000aaaa"
+	ClientID     = "00000000-0000-0000-0000-00000000bbbb"
+	ClientSecret = "fake-client-secret"
+)
+
+const operationsPath = "/fakearm/operations/"
+
+// Server is a running fake. All methods are safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	accounts   map[string]*CosmosDBAccount
+	registries map[string]*ContainerRegistry
+	namespaces map[string]*Namespace
+	operations map[string]*operation
+	tokens     map[string]issuedToken
+	// subscriptionTenants maps lower-cased subscription IDs to their tenant when it's not TenantID.
+	subscriptionTenants map[string]string
+	permissions         []client.Permission
+	serviceTags         servicetags.ServiceTags
+	resourceGraphRows   []any
+	resourceGraphQuery  client.ResourceGraphQuery
+	requests            []Request
+	updateDelay         time.Duration
+	pageSize            int
+	throttled           int
+	failures            []failure
+	tokenTTL            time.Duration
+	// tokenExtTTL is the extended lifetime of issued tokens, tokenTTL when zero.
+	tokenExtTTL    time.Duration
+	tokenRefreshIn time.Duration
+	tokenDelay     time.Duration
+	// tokenFailures are the statuses of the next token requests to fail.
+	tokenFailures []int
+	sequence      atomic.Int64
+}
+
+// CosmosDBAccount is the fake state of a CosmosDB account.
+type CosmosDBAccount struct {
+	ID                  string
+	Location            string
+	Tags                map[string]string
+	IpRules             []string
+	VirtualNetworkRules []string
+	PublicNetworkAccess bool
+}
+
+// ContainerRegistry is the fake state of a Container Registry.
+type ContainerRegistry struct {
+	ID            string
+	IpRules       []string
+	DefaultAction string
+}
+
+// Namespace is the fake state of the `networkRuleSets/default` of an Event Hubs or Service Bus namespace.
+type Namespace struct {
+	ID                          string
+	IpRules                     []string
+	DefaultAction               string
+	VirtualNetworkRules         []string
+	TrustedServiceAccessEnabled bool
+	PublicNetworkAccess         bool
+}
+
+// Request is a request received by the fake, recorded for assertions.
+type Request struct {
+	Method string
+	Path   string
+	// Tenant is the tenant of the `Authorization` token, AuxiliaryTenants those of the `x-ms-authorization-auxiliary`
+	// tokens. They're empty for token requests and unknown tokens.
+	Tenant           string
+	AuxiliaryTenants []string
+}
+
+// operation is an update of the resource at key, applied once it's ready unless it fails.
+type operation struct {
+	key     string
+	apply   func()
+	readyAt time.Time
+	failure *failure
+	done    bool
+}
+
+type issuedToken struct {
+	tenantId  string
+	expiresAt time.Time
+}
+
+type failure struct {
+	code    string
+	message string
+}
+
+// New starts a fake server which is closed when the test ends.
+func New(t testing.TB) *Server {
+	s := &Server{
+		accounts:            map[string]*CosmosDBAccount{},
+		registries:          map[string]*ContainerRegistry{},
+		namespaces:          map[string]*Namespace{},
+		operations:          map[string]*operation{},
+		tokens:              map[string]issuedToken{},
+		subscriptionTenants: map[string]string{},
+		permissions:         []client.Permission{{Actions: []string{"*"}}},
+		tokenTTL:            time.Hour,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// ClientOptions returns the options pointing a client.Client at the fake, with a short poll interval.
+func (s *Server) ClientOptions() []client.Option {
+	return []client.Option{
+		client.WithHTTPClient(s.Client()),
+		client.WithResourceManagerEndpoint(s.URL),
+		client.WithAuthorityHost(s.URL),
+		client.WithPollInterval(10 * time.Millisecond),
+	}
+}
+
+// ProviderConfig returns a provider block authenticating against the fake.
+func (s *Server) ProviderConfig() string {
+	return fmt.Sprintf(`
+provider "azurermext" {
+  tenant_id     = %q
+  client_id     = %q
+  client_secret = %q
+}
+`, TenantID, ClientID, ClientSecret)
+}
+
+// AddCosmosDBAccount creates a publicly accessible account in westeurope with the given IP rules.
+func (s *Server) AddCosmosDBAccount(id string, ipRules []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts[strings.ToLower(id)] = &CosmosDBAccount{ID: id, Location: "westeurope", Tags: map[string]string{}, IpRules: append([]string{}, ipRules...), PublicNetworkAccess: true}
+}
+
+// UpdateCosmosDBAccount changes an account out of band, e.g. to simulate IPs added by someone else.
+func (s *Server) UpdateCosmosDBAccount(id string, update func(account *CosmosDBAccount)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if account, ok := s.accounts[strings.ToLower(id)]; ok {
+		update(account)
+	}
+}
+
+// RemoveCosmosDBAccount deletes an account so that it answers 404.
+func (s *Server) RemoveCosmosDBAccount(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.accounts, strings.ToLower(id))
+}
+
+// CosmosDBAccount returns a copy of an account's current state.
+func (s *Server) CosmosDBAccount(id string) (CosmosDBAccount, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account, ok := s.accounts[strings.ToLower(id)]
+	if !ok {
+		return CosmosDBAccount{}, false
+	}
+	copied := *account
+	copied.IpRules = append([]string{}, account.IpRules...)
+	copied.VirtualNetworkRules = append([]string{}, account.VirtualNetworkRules...)
+	copied.Tags = make(map[string]string, len(account.Tags))
+	for name, value := range account.Tags {
+		copied.Tags[name] = value
+	}
+	return copied, true
+}
+
+// AddContainerRegistry creates a Premium registry with the given IP rules and a Deny default action.
+func (s *Server) AddContainerRegistry(id string, ipRules []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.registries[strings.ToLower(id)] = &ContainerRegistry{ID: id, IpRules: append([]string{}, ipRules...), DefaultAction: "Deny"}
+}
+
+// ContainerRegistry returns a copy of a registry's current state.
+func (s *Server) ContainerRegistry(id string) (ContainerRegistry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	registry, ok := s.registries[strings.ToLower(id)]
+	if !ok {
+		return ContainerRegistry{}, false
+	}
+	copied := *registry
+	copied.IpRules = append([]string{}, registry.IpRules...)
+	return copied, true
+}
+
+// AddNamespace creates an Event Hubs or Service Bus namespace, depending on the type of id, whose network rule set
+// has the given IP rules, a Deny default action and trusted service access enabled.
+func (s *Server) AddNamespace(id string, ipRules []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.namespaces[strings.ToLower(id)] = &Namespace{
+		ID:                          id,
+		IpRules:                     append([]string{}, ipRules...),
+		DefaultAction:               "Deny",
+		TrustedServiceAccessEnabled: true,
+		PublicNetworkAccess:         true,
+	}
+}
+
+// UpdateNamespace changes the network rule set of a namespace out of band.
+func (s *Server) UpdateNamespace(id string, update func(namespace *Namespace)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if namespace, ok := s.namespaces[strings.ToLower(id)]; ok {
+		update(namespace)
+	}
+}
+
+// RemoveNamespace deletes a namespace so that its network rule set answers 404.
+func (s *Server) RemoveNamespace(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.namespaces, strings.ToLower(id))
+}
+
+// Namespace returns a copy of the network rule set of a namespace.
+func (s *Server) Namespace(id string) (Namespace, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	namespace, ok := s.namespaces[strings.ToLower(id)]
+	if !ok {
+		return Namespace{}, false
+	}
+	copied := *namespace
+	copied.IpRules = append([]string{}, namespace.IpRules...)
+	copied.VirtualNetworkRules = append([]string{}, namespace.VirtualNetworkRules...)
+	return copied, true
+}
+
+// SetSubscriptionTenant moves a subscription to another tenant, which the service principal can then authenticate
+// to. ARM requests to the subscription are rejected unless their token was issued by that tenant.
+func (s *Server) SetSubscriptionTenant(subscriptionId, tenantId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscriptionTenants[strings.ToLower(subscriptionId)] = tenantId
+}
+
+// SetPermissions sets the permissions the service principal has on every scope. It's granted everything by default.
+func (s *Server) SetPermissions(permissions ...client.Permission) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.permissions = permissions
+}
+
+// SetServiceTags sets the service tags returned by the serviceTags API, whatever the subscription and location.
+// There's none by default.
+func (s *Server) SetServiceTags(tags ...servicetags.ServiceTag) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.serviceTags = servicetags.ServiceTags{ChangeNumber: "1", Cloud: "Public", Values: tags}
+}
+
+// SetResourceGraphRows sets the rows returned by Resource Graph. The query isn't evaluated: every query returns them,
+// paginated with `$skipToken` like the list operations.
+func (s *Server) SetResourceGraphRows(rows ...map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resourceGraphRows = nil
+	for _, row := range rows {
+		s.resourceGraphRows = append(s.resourceGraphRows, row)
+	}
+}
+
+// LastResourceGraphQuery returns the last query sent to Resource Graph.
+func (s *Server) LastResourceGraphQuery() client.ResourceGraphQuery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.resourceGraphQuery
+}
+
+// SetUpdateDelay makes account updates stay InProgress for the given duration.
+func (s *Server) SetUpdateDelay(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updateDelay = delay
+}
+
+// SetPageSize makes list operations return at most n items per page, linking to the next page with nextLink. Lists
+// have a single page by default.
+func (s *Server) SetPageSize(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pageSize = n
+}
+
+// SetTokenTTL sets the lifetime of issued tokens, reported in `expires_in`.
+func (s *Server) SetTokenTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenTTL = ttl
+}
+
+// SetTokenExtendedTTL sets the extended lifetime of issued tokens, reported in `ext_expires_in`. It defaults to the
+// token TTL.
+func (s *Server) SetTokenExtendedTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenExtTTL = ttl
+}
+
+// SetTokenRefreshIn makes the token endpoint send `refresh_in`, which it doesn't by default.
+func (s *Server) SetTokenRefreshIn(refreshIn time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenRefreshIn = refreshIn
+}
+
+// SetTokenDelay makes the token endpoint answer after the given delay. Other requests aren't delayed.
+func (s *Server) SetTokenDelay(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenDelay = delay
+}
+
+// FailTokenRequests makes the next n token requests fail with the given HTTP status, e.g. 503 for an AAD outage.
+func (s *Server) FailTokenRequests(n, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for range n {
+		s.tokenFailures = append(s.tokenFailures, status)
+	}
+}
+
+// Throttle makes the next n ARM requests answer 429 with a `Retry-After` of one second.
+func (s *Server) Throttle(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.throttled = n
+}
+
+// FailNextUpdate makes the async operation of the next account or registry update end in Failed with the given error.
+func (s *Server) FailNextUpdate(code, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, failure{code, message})
+}
+
+// Requests returns the requests received so far, token requests included.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request{}, s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token") {
+		s.mu.Lock()
+		delay := s.tokenDelay
+		s.mu.Unlock()
+		time.Sleep(delay)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path})
+	w.Header().Set("x-ms-request-id", s.newID("request"))
+	w.Header().Set("x-ms-correlation-request-id", s.newID("correlation"))
+
+	if strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token") {
+		s.serveToken(w, r)
+		return
+	}
+	issued, ok := s.authorized(r)
+	recorded := &s.requests[len(s.requests)-1]
+	recorded.Tenant = issued.tenantId
+	for _, auxiliary := range strings.Split(r.Header.Get("x-ms-authorization-auxiliary"), ",") {
+		if token, ok := strings.CutPrefix(strings.TrimSpace(auxiliary), "Bearer "); ok {
+			recorded.AuxiliaryTenants = append(recorded.AuxiliaryTenants, s.tokens[token].tenantId)
+		}
+	}
+	if !ok {
+		writeARMError(w, http.StatusUnauthorized, "AuthenticationFailed", "Authentication failed. The 'Authorization' header is missing or invalid.")
+		return
+	}
+	if tenant := s.subscriptionTenant(r.URL.Path); tenant != "" && tenant != issued.tenantId {
+		w.Header().Set("WWW-Authenticate", `Bearer authorization_uri="https://login.windows.net/`+tenant+`", error="invalid_token", error_description="The access token is from the wrong issuer."`)
+		writeARMError(w, http.StatusUnauthorized, "InvalidAuthenticationTokenTenant",
+			"The access token is from the wrong issuer 'https://sts.windows.net/"+issued.tenantId+"/'. It must match the tenant 'https://sts.windows.net/"+tenant+"/' associated with this subscription.")
+		return
+	}
+	if s.throttled > 0 {
+		s.throttled--
+		w.Header().Set("Retry-After", "1")
+		writeARMError(w, http.StatusTooManyRequests, "TooManyRequests", "The request is being throttled.")
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, operationsPath) && r.Method == http.MethodGet:
+		s.serveOperation(w, strings.TrimPrefix(r.URL.Path, operationsPath))
+	case strings.HasSuffix(strings.ToLower(r.URL.Path), "/providers/microsoft.authorization/permissions") && r.Method == http.MethodGet:
+		permissions := []any{}
+		for _, permission := range s.permissions {
+			permissions = append(permissions, permission)
+		}
+		s.writePage(w, r, permissions)
+	case strings.EqualFold(r.URL.Path, "/providers/Microsoft.ResourceGraph/resources") && r.Method == http.MethodPost:
+		s.serveResourceGraph(w, r)
+	case strings.Contains(strings.ToLower(r.URL.Path), "/providers/microsoft.network/locations/") &&
+		strings.HasSuffix(strings.ToLower(r.URL.Path), "/servicetags") && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.serviceTags)
+	case strings.HasSuffix(strings.ToLower(r.URL.Path), "/providers/microsoft.resources/tags/default") && r.Method == http.MethodPatch:
+		s.serveTags(w, r)
+	case strings.HasSuffix(strings.ToLower(r.URL.Path), "/providers/microsoft.documentdb/databaseaccounts") && r.Method == http.MethodGet:
+		s.serveCosmosDBAccounts(w, r)
+	case strings.Contains(strings.ToLower(r.URL.Path), "/providers/microsoft.documentdb/databaseaccounts/"):
+		s.serveCosmosDBAccount(w, r)
+	case strings.Contains(strings.ToLower(r.URL.Path), "/providers/microsoft.containerregistry/registries/"):
+		s.serveContainerRegistry(w, r)
+	case strings.HasSuffix(strings.ToLower(r.URL.Path), "/networkrulesets/default"):
+		s.serveNamespaceNetworkRuleSet(w, r)
+	default:
+		writeARMError(w, http.StatusNotFound, "NotFound", "fakearm doesn't emulate "+r.Method+" "+r.URL.Path)
+	}
+}
+
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+	tenant := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")[0]
+	if tenant != TenantID && !s.isKnownTenant(tenant) {
+		writeAADError(w, http.StatusBadRequest, "invalid_request", 90002, "AADSTS90002: Tenant '"+tenant+"' not found.")
+		return
+	}
+	if r.PostForm.Get("client_id") != ClientID {
+		writeAADError(w, http.StatusBadRequest, "unauthorized_client", 700016, "AADSTS700016: Application with identifier '"+r.PostForm.Get("client_id")+"' was not found in the directory.")
+		return
+	}
+	if r.PostForm.Get("client_secret") != ClientSecret {
+		writeAADError(w, http.StatusUnauthorized, "invalid_client", 7000215, "AADSTS7000215: Invalid client secret provided.")
+		return
+	}
+	if len(s.tokenFailures) > 0 {
+		status := s.tokenFailures[0]
+		s.tokenFailures = s.tokenFailures[1:]
+		if status >= http.StatusInternalServerError {
+			writeAADError(w, status, "temporarily_unavailable", 50000, "AADSTS50000: There was an error issuing a token or an issue with our sign-in service.")
+		} else {
+			writeAADError(w, status, "invalid_request", 90014, "AADSTS90014: The request is invalid.")
+		}
+		return
+	}
+
+	token := s.newID("token")
+	s.tokens[token] = issuedToken{tenantId: tenant, expiresAt: time.Now().Add(s.tokenTTL)}
+	extTTL := s.tokenExtTTL
+	if extTTL == 0 {
+		extTTL = s.tokenTTL
+	}
+	body := map[string]any{
+		"token_type":     "Bearer",
+		"expires_in":     int(s.tokenTTL / time.Second),
+		"ext_expires_in": int(extTTL / time.Second),
+		"access_token":   token,
+	}
+	if s.tokenRefreshIn > 0 {
+		body["refresh_in"] = int(s.tokenRefreshIn / time.Second)
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) authorized(r *http.Request) (issuedToken, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return issuedToken{}, false
+	}
+	issued, ok := s.tokens[token]
+	return issued, ok && time.Now().Before(issued.expiresAt)
+}
+
+// subscriptionTenant returns the tenant of the subscription targeted by path, or "" for paths outside of a
+// subscription.
+func (s *Server) subscriptionTenant(path string) string {
+	segments := strings.Split(strings.ToLower(path), "/")
+	if len(segments) < 3 || segments[1] != "subscriptions" {
+		return ""
+	}
+	if tenant, ok := s.subscriptionTenants[segments[2]]; ok {
+		return tenant
+	}
+	return TenantID
+}
+
+func (s *Server) isKnownTenant(tenant string) bool {
+	for _, known := range s.subscriptionTenants {
+		if known == tenant {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) serveCosmosDBAccount(w http.ResponseWriter, r *http.Request) {
+	key := strings.ToLower(r.URL.Path)
+	account, ok := s.accounts[key]
+	if !ok {
+		writeARMError(w, http.StatusNotFound, "ResourceNotFound", "The Resource '"+r.URL.Path+"' was not found.")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, cosmosDBAccountBody(account, s.hasPendingOperation(key)))
+	case http.MethodPatch:
+		var body client.CosmosDBResponse
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Properties == nil {
+			writeARMError(w, http.StatusBadRequest, "BadRequest", "invalid request body")
+			return
+		}
+		if s.hasPendingOperation(key) {
+			writeARMError(w, http.StatusPreconditionFailed, "PreconditionFailed", "There is already an operation in progress which requires exclusive lock on this service.")
+			return
+		}
+		ipRules := []string{}
+		for _, rule := range body.Properties.IpRules {
+			ipRules = append(ipRules, rule.IpAddressOrRange)
+		}
+		s.startOperation(w, key, func() { account.IpRules = ipRules })
+		writeJSON(w, http.StatusOK, cosmosDBAccountBody(account, true))
+	default:
+		writeARMError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method+" is not emulated for CosmosDB accounts")
+	}
+}
+
+func (s *Server) serveContainerRegistry(w http.ResponseWriter, r *http.Request) {
+	key := strings.ToLower(r.URL.Path)
+	registry, ok := s.registries[key]
+	if !ok {
+		writeARMError(w, http.StatusNotFound, "ResourceNotFound", "The Resource '"+r.URL.Path+"' was not found.")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, containerRegistryBody(registry, s.hasPendingOperation(key)))
+	case http.MethodPatch:
+		var body client.ContainerRegistryResponse
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Properties == nil || body.Properties.NetworkRuleSet == nil {
+			writeARMError(w, http.StatusBadRequest, "BadRequest", "invalid request body")
+			return
+		}
+		if s.hasPendingOperation(key) {
+			writeARMError(w, http.StatusConflict, "Conflict", "Another operation is in progress on the registry.")
+			return
+		}
+		ipRules := []string{}
+		for _, rule := range body.Properties.NetworkRuleSet.IpRules {
+			ipRules = append(ipRules, rule.Value)
+		}
+		defaultAction := string(body.Properties.NetworkRuleSet.DefaultAction)
+		s.startOperation(w, key, func() { registry.IpRules, registry.DefaultAction = ipRules, defaultAction })
+		writeJSON(w, http.StatusOK, containerRegistryBody(registry, true))
+	default:
+		writeARMError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method+" is not emulated for Container Registries")
+	}
+}
+
+// namespaceNetworkRuleSetBody is the JSON of the network rule set of a namespace.
+type namespaceNetworkRuleSetBody struct {
+	Properties *struct {
+		DefaultAction               string `json:"defaultAction"`
+		TrustedServiceAccessEnabled *bool  `json:"trustedServiceAccessEnabled"`
+		PublicNetworkAccess         string `json:"publicNetworkAccess"`
+		IpRules                     []struct {
+			IpMask string `json:"ipMask"`
+			Action string `json:"action"`
+		} `json:"ipRules"`
+		VirtualNetworkRules []struct {
+			Subnet struct {
+				ID string `json:"id"`
+			} `json:"subnet"`
+		} `json:"virtualNetworkRules"`
+	} `json:"properties"`
+}
+
+// serveNamespaceNetworkRuleSet serves the `networkRuleSets/default` of Event Hubs and Service Bus namespaces. A PUT
+// replaces the whole rule set, so properties missing from the body are reset like Azure does.
+func (s *Server) serveNamespaceNetworkRuleSet(w http.ResponseWriter, r *http.Request) {
+	key := strings.ToLower(r.URL.Path[:len(r.URL.Path)-len("/networkRuleSets/default")])
+	namespace, ok := s.namespaces[key]
+	if !ok {
+		writeARMError(w, http.StatusNotFound, "ResourceNotFound", "The Resource '"+r.URL.Path+"' was not found.")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, namespaceBody(namespace))
+	case http.MethodPut:
+		var body namespaceNetworkRuleSetBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Properties == nil {
+			writeARMError(w, http.StatusBadRequest, "BadRequest", "invalid request body")
+			return
+		}
+		if s.hasPendingOperation(key) {
+			writeARMError(w, http.StatusConflict, "Conflict", "Another operation is in progress on the namespace.")
+			return
+		}
+		updated := Namespace{
+			ID:                          namespace.ID,
+			IpRules:                     []string{},
+			VirtualNetworkRules:         []string{},
+			DefaultAction:               body.Properties.DefaultAction,
+			TrustedServiceAccessEnabled: body.Properties.TrustedServiceAccessEnabled != nil && *body.Properties.TrustedServiceAccessEnabled,
+			PublicNetworkAccess:         body.Properties.PublicNetworkAccess == "Enabled",
+		}
+		for _, rule := range body.Properties.IpRules {
+			updated.IpRules = append(updated.IpRules, rule.IpMask)
+		}
+		for _, rule := range body.Properties.VirtualNetworkRules {
+			updated.VirtualNetworkRules = append(updated.VirtualNetworkRules, rule.Subnet.ID)
+		}
+		s.startOperation(w, key, func() { *namespace = updated })
+		writeJSON(w, http.StatusOK, namespaceBody(namespace))
+	default:
+		writeARMError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method+" is not emulated for namespace network rule sets")
+	}
+}
+
+// startOperation registers an update of the resource at key, applied once the update delay elapsed, and points the
+// response at it. The next failure injected with FailNextUpdate is consumed by it.
+func (s *Server) startOperation(w http.ResponseWriter, key string, apply func()) {
+	op := &operation{key: key, apply: apply, readyAt: time.Now().Add(s.updateDelay)}
+	if len(s.failures) > 0 {
+		failure := s.failures[0]
+		op.failure = &failure
+		s.failures = s.failures[1:]
+	}
+	operationId := s.newID("operation")
+	s.operations[operationId] = op
+	w.Header().Set("Azure-AsyncOperation", s.URL+operationsPath+operationId+"?api-version=2025-04-15")
+	w.Header().Set("Retry-After", "0")
+}
+
+// serveCosmosDBAccounts lists the accounts of a subscription or resource group, sorted by ID.
+func (s *Server) serveCosmosDBAccounts(w http.ResponseWriter, r *http.Request) {
+	scope := strings.ToLower(r.URL.Path[:len(r.URL.Path)-len("/providers/Microsoft.DocumentDB/databaseAccounts")]) + "/"
+	var keys []string
+	for key := range s.accounts {
+		if strings.HasPrefix(key, scope) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	accounts := []any{}
+	for _, key := range keys {
+		accounts = append(accounts, cosmosDBAccountBody(s.accounts[key], s.hasPendingOperation(key)))
+	}
+	s.writePage(w, r, accounts)
+}
+
+// writePage answers a list operation with the page of values selected by the `$skipToken` of the request, the
+// offset of its first value.
+func (s *Server) writePage(w http.ResponseWriter, r *http.Request, values []any) {
+	offset := 0
+	if token := r.URL.Query().Get("$skipToken"); token != "" {
+		var err error
+		if offset, err = strconv.Atoi(token); err != nil || offset < 0 || offset > len(values) {
+			writeARMError(w, http.StatusBadRequest, "InvalidSkipToken", "invalid $skipToken "+token)
+			return
+		}
+	}
+	body := map[string]any{}
+	end := len(values)
+	if s.pageSize > 0 && offset+s.pageSize < end {
+		end = offset + s.pageSize
+		next := *r.URL
+		query := next.Query()
+		query.Set("$skipToken", strconv.Itoa(end))
+		next.RawQuery = query.Encode()
+		body["nextLink"] = s.URL + next.RequestURI()
+	}
+	body["value"] = values[offset:end]
+	writeJSON(w, http.StatusOK, body)
+}
+
+// serveResourceGraph answers every query with the rows set by SetResourceGraphRows.
+func (s *Server) serveResourceGraph(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		client.ResourceGraphQuery
+		Options struct {
+			SkipToken string `json:"$skipToken"`
+			Top       int    `json:"$top"`
+		} `json:"options"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Query == "" {
+		writeARMError(w, http.StatusBadRequest, "BadRequest", "invalid request body")
+		return
+	}
+	s.resourceGraphQuery = body.ResourceGraphQuery
+	offset := 0
+	if body.Options.SkipToken != "" {
+		var err error
+		if offset, err = strconv.Atoi(body.Options.SkipToken); err != nil || offset < 0 || offset > len(s.resourceGraphRows) {
+			writeARMError(w, http.StatusBadRequest, "InvalidSkipToken", "invalid $skipToken "+body.Options.SkipToken)
+			return
+		}
+	}
+	pageSize := body.Options.Top
+	if s.pageSize > 0 && (pageSize == 0 || s.pageSize < pageSize) {
+		pageSize = s.pageSize
+	}
+	end := len(s.resourceGraphRows)
+	response := map[string]any{"totalRecords": end, "resultTruncated": "false"}
+	if pageSize > 0 && offset+pageSize < end {
+		end = offset + pageSize
+		response["$skipToken"] = strconv.Itoa(end)
+	}
+	rows := append([]any{}, s.resourceGraphRows[offset:end]...)
+	response["data"], response["count"] = rows, len(rows)
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) serveTags(w http.ResponseWriter, r *http.Request) {
+	key := strings.ToLower(r.URL.Path[:len(r.URL.Path)-len("/providers/Microsoft.Resources/tags/default")])
+	account, ok := s.accounts[key]
+	if !ok {
+		writeARMError(w, http.StatusNotFound, "ResourceNotFound", "The Resource '"+r.URL.Path+"' was not found.")
+		return
+	}
+	var body struct {
+		Operation  string `json:"operation"`
+		Properties struct {
+			Tags map[string]string `json:"tags"`
+		} `json:"properties"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeARMError(w, http.StatusBadRequest, "BadRequest", "invalid request body")
+		return
+	}
+	for name, value := range body.Properties.Tags {
+		switch body.Operation {
+		case "Merge":
+			account.Tags[name] = value
+		case "Delete":
+			delete(account.Tags, name)
+		default:
+			writeARMError(w, http.StatusBadRequest, "InvalidTagOperation", "operation "+body.Operation+" is not emulated")
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"properties": map[string]any{"tags": account.Tags}})
+}
+
+func (s *Server) serveOperation(w http.ResponseWriter, operationId string) {
+	op, ok := s.operations[operationId]
+	if !ok {
+		writeARMError(w, http.StatusNotFound, "NotFound", "Operation "+operationId+" not found.")
+		return
+	}
+	if time.Now().Before(op.readyAt) {
+		writeJSON(w, http.StatusOK, map[string]any{"status": "InProgress"})
+		return
+	}
+	if op.failure != nil {
+		op.done = true
+		writeJSON(w, http.StatusOK, map[string]any{
+			"status": "Failed",
+			"error":  map[string]any{"code": op.failure.code, "message": op.failure.message},
+		})
+		return
+	}
+	if !op.done {
+		op.done = true
+		op.apply()
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"status": "Succeeded"})
+}
+
+func (s *Server) hasPendingOperation(key string) bool {
+	for _, op := range s.operations {
+		if op.key == key && !op.done {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) newID(kind string) string {
+	return fmt.Sprintf("fakearm-%s-%d", kind, s.sequence.Add(1))
+}
+
+func cosmosDBAccountBody(account *CosmosDBAccount, updating bool) map[string]any {
+	ipRules := []map[string]string{}
+	for _, ip := range account.IpRules {
+		ipRules = append(ipRules, map[string]string{"ipAddressOrRange": ip})
+	}
+	virtualNetworkRules := []map[string]any{}
+	for _, subnetId := range account.VirtualNetworkRules {
+		virtualNetworkRules = append(virtualNetworkRules, map[string]any{"id": subnetId, "ignoreMissingVNetServiceEndpoint": false})
+	}
+	publicNetworkAccess, provisioningState := "Disabled", "Succeeded"
+	if account.PublicNetworkAccess {
+		publicNetworkAccess = "Enabled"
+	}
+	if updating {
+		provisioningState = "Updating"
+	}
+	return map[string]any{
+		"id":       account.ID,
+		"name":     account.ID[strings.LastIndex(account.ID, "/")+1:],
+		"type":     "Microsoft.DocumentDB/databaseAccounts",
+		"location": account.Location,
+		"tags":     account.Tags,
+		"properties": map[string]any{
+			"provisioningState":   provisioningState,
+			"ipRules":             ipRules,
+			"virtualNetworkRules": virtualNetworkRules,
+			"publicNetworkAccess": publicNetworkAccess,
+		},
+	}
+}
+
+func containerRegistryBody(registry *ContainerRegistry, updating bool) map[string]any {
+	ipRules := []map[string]string{}
+	for _, ip := range registry.IpRules {
+		ipRules = append(ipRules, map[string]string{"action": "Allow", "value": ip})
+	}
+	provisioningState := "Succeeded"
+	if updating {
+		provisioningState = "Updating"
+	}
+	return map[string]any{
+		"id":       registry.ID,
+		"name":     registry.ID[strings.LastIndex(registry.ID, "/")+1:],
+		"type":     "Microsoft.ContainerRegistry/registries",
+		"location": "westeurope",
+		"sku":      map[string]any{"name": "Premium", "tier": "Premium"},
+		"properties": map[string]any{
+			"provisioningState":   provisioningState,
+			"publicNetworkAccess": "Enabled",
+			"networkRuleSet":      map[string]any{"defaultAction": registry.DefaultAction, "ipRules": ipRules},
+		},
+	}
+}
+
+func namespaceBody(namespace *Namespace) map[string]any {
+	ipRules := []map[string]string{}
+	for _, ip := range namespace.IpRules {
+		ipRules = append(ipRules, map[string]string{"ipMask": ip, "action": "Allow"})
+	}
+	virtualNetworkRules := []map[string]any{}
+	for _, subnetId := range namespace.VirtualNetworkRules {
+		virtualNetworkRules = append(virtualNetworkRules, map[string]any{"subnet": map[string]string{"id": subnetId}, "ignoreMissingVnetServiceEndpoint": false})
+	}
+	publicNetworkAccess := "Disabled"
+	if namespace.PublicNetworkAccess {
+		publicNetworkAccess = "Enabled"
+	}
+	return map[string]any{
+		"id":   namespace.ID + "/networkRuleSets/default",
+		"name": "default",
+		"properties": map[string]any{
+			"defaultAction":               namespace.DefaultAction,
+			"trustedServiceAccessEnabled": namespace.TrustedServiceAccessEnabled,
+			"publicNetworkAccess":         publicNetworkAccess,
+			"ipRules":                     ipRules,
+			"virtualNetworkRules":         virtualNetworkRules,
+		},
+	}
+}
+
+func writeARMError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]any{"error": map[string]any{"code": code, "message": message}})
+}
+
+func writeAADError(w http.ResponseWriter, status int, errorCode string, aadstsCode int, description string) {
+	writeJSON(w, status, map[string]any{
+		"error":             errorCode,
+		"error_description": description,
+		"error_codes":       []int{aadstsCode},
+		"trace_id":          "00000000-0000-0000-0000-000000000001",
+		"correlation_id":    "00000000-0000-0000-0000-000000000002",
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,234 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"terraform-provider-azurermext/internal/cidr"
+	"terraform-provider-azurermext/internal/resourceid"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ validator.String = resourceIdValidator{}
+	_ validator.List   = resourceIdValidator{}
+	_ validator.String = scopeValidator{}
+	_ validator.List   = scopeValidator{}
+	_ validator.String = oneOfValidator{}
+	_ validator.String = rfc3339Validator{}
+	_ validator.String = ipRangeValidator{}
+	_ validator.List   = ipRangeValidator{}
+	_ validator.String = tagNameValidator{}
+)
+
+// resourceIdValidator checks at plan time that a string is an ARM resource ID of the given resource type.
+type resourceIdValidator struct {
+	resourceType string
+}
+
+func (v resourceIdValidator) Description(_ context.Context) string {
+	return "value must be the resource ID of a " + v.resourceType
+}
+
+func (v resourceIdValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v resourceIdValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := resourceid.ParseAs(req.ConfigValue.ValueString(), v.resourceType); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid resource ID",
+			err.Error(),
+		)
+	}
+}
+
+func (v resourceIdValidator) ValidateList(_ context.Context, req validator.ListRequest, resp *validator.ListResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	for i, element := range req.ConfigValue.Elements() {
+		value, ok := element.(types.String)
+		if !ok || value.IsNull() || value.IsUnknown() {
+			continue
+		}
+		if _, err := resourceid.ParseAs(value.ValueString(), v.resourceType); err != nil {
+			resp.Diagnostics.AddAttributeError(req.Path.AtListIndex(i), "Invalid resource ID", err.Error())
+		}
+	}
+}
+
+// scopeValidator checks that a string is the ID of a subscription or a resource group.
+type scopeValidator struct{}
+
+func (v scopeValidator) Description(_ context.Context) string {
+	return "value must be the ID of a subscription or a resource group"
+}
+
+func (v scopeValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v scopeValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if !isScope(req.ConfigValue.ValueString()) {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid scope",
+			"Got "+req.ConfigValue.String()+", "+v.Description(ctx)+", e.g. /subscriptions/{id}/resourceGroups/{name}.",
+		)
+	}
+}
+
+func (v scopeValidator) ValidateList(ctx context.Context, req validator.ListRequest, resp *validator.ListResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	for i, element := range req.ConfigValue.Elements() {
+		value, ok := element.(types.String)
+		if !ok || value.IsNull() || value.IsUnknown() || isScope(value.ValueString()) {
+			continue
+		}
+		resp.Diagnostics.AddAttributeError(
+			req.Path.AtListIndex(i),
+			"Invalid scope",
+			"Got "+value.String()+", "+v.Description(ctx)+", e.g. /subscriptions/{id}/resourceGroups/{name}.",
+		)
+	}
+}
+
+func isScope(value string) bool {
+	id, err := resourceid.Parse(value)
+	return err == nil && id.ResourceType() == ""
+}
+
+// oneOfValidator checks that a string is one of a fixed set of values.
+type oneOfValidator struct {
+	values []string
+}
+
+func (v oneOfValidator) Description(_ context.Context) string {
+	return "value must be one of: " + strings.Join(v.values, ", ")
+}
+
+func (v oneOfValidator) MarkdownDescription(_ context.Context) string {
+	return "value must be one of: `" + strings.Join(v.values, "`, `") + "`"
+}
+
+func (v oneOfValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	for _, value := range v.values {
+		if req.ConfigValue.ValueString() == value {
+			return
+		}
+	}
+	resp.Diagnostics.AddAttributeError(
+		req.Path,
+		"Invalid value",
+		"Got "+req.ConfigValue.String()+", "+v.Description(ctx)+".",
+	)
+}
+
+// rfc3339Validator checks that a string is an RFC 3339 timestamp such as "2025-01-31T18:00:00Z".
+type rfc3339Validator struct{}
+
+func (v rfc3339Validator) Description(_ context.Context) string {
+	return "value must be an RFC 3339 timestamp such as 2025-01-31T18:00:00Z"
+}
+
+func (v rfc3339Validator) MarkdownDescription(_ context.Context) string {
+	return "value must be an RFC 3339 timestamp such as `2025-01-31T18:00:00Z`"
+}
+
+func (v rfc3339Validator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := time.Parse(time.RFC3339, req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid timestamp",
+			"Got "+req.ConfigValue.String()+", "+v.Description(ctx)+".",
+		)
+	}
+}
+
+// ipRangeValidator checks that a string, or every string of a list, is an IP address or a CIDR range as parsed by
+// package cidr, like the cidr_* functions do.
+type ipRangeValidator struct{}
+
+func (v ipRangeValidator) Description(_ context.Context) string {
+	return "value must be an IP address or a CIDR range such as 10.0.0.0/24"
+}
+
+func (v ipRangeValidator) MarkdownDescription(_ context.Context) string {
+	return "value must be an IP address or a CIDR range such as `10.0.0.0/24`"
+}
+
+func (v ipRangeValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := cidr.ParsePrefix(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid IP rule", err.Error()+".")
+	}
+}
+
+func (v ipRangeValidator) ValidateList(_ context.Context, req validator.ListRequest, resp *validator.ListResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	for i, element := range req.ConfigValue.Elements() {
+		value, ok := element.(types.String)
+		if !ok || value.IsNull() || value.IsUnknown() {
+			continue
+		}
+		if _, err := cidr.ParsePrefix(value.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(req.Path.AtListIndex(i), "Invalid IP rule", err.Error()+".")
+		}
+	}
+}
+
+// tagNameValidator checks that a string is a tag name Azure accepts, leaving room for the ".N" suffixes of the
+// tags package rulemetadata splits long values over.
+type tagNameValidator struct{}
+
+const (
+	// maxTagNameLength is the maximum length of an Azure tag name, minus the longest rulemetadata suffix.
+	maxTagNameLength = 512 - 2
+	// forbiddenTagNameCharacters can't be used in the name of a tag.
+	forbiddenTagNameCharacters = `<>%&\?/`
+)
+
+func (v tagNameValidator) Description(_ context.Context) string {
+	return fmt.Sprintf("value must be a tag name of 1 to %d characters, without any of %s", maxTagNameLength, forbiddenTagNameCharacters)
+}
+
+func (v tagNameValidator) MarkdownDescription(_ context.Context) string {
+	return fmt.Sprintf("value must be a tag name of 1 to %d characters, without any of `%s`", maxTagNameLength, forbiddenTagNameCharacters)
+}
+
+func (v tagNameValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	name := req.ConfigValue.ValueString()
+	if name == "" || len(name) > maxTagNameLength || strings.ContainsAny(name, forbiddenTagNameCharacters) {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid tag name",
+			"Got "+req.ConfigValue.String()+", "+v.Description(ctx)+".",
+		)
+	}
+}